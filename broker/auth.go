@@ -0,0 +1,137 @@
+package broker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/oauth"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"os"
+	"strings"
+)
+
+const (
+	SaslNone        = "NONE"
+	SaslPlain       = "PLAIN"
+	SaslScramSha256 = "SCRAM-SHA-256"
+	SaslScramSha512 = "SCRAM-SHA-512"
+	SaslOauthBearer = "OAUTHBEARER"
+)
+
+type TlsConfig struct {
+	CaFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// SaslConfig holds the sasl credentials. If a file is set, the value is read from the file (for example a mounted
+// secret) on every authentication and the plain value is ignored.
+type SaslConfig struct {
+	Mechanism    string
+	Username     string
+	UsernameFile string
+	Password     string
+	PasswordFile string
+	Token        string
+	TokenFile    string
+}
+
+// NewTlsConfig creates the tls configuration for connecting to the brokers. Without ca file the system root
+// certificates are used. Client certificate and key are optional and only needed for mutual tls.
+func NewTlsConfig(config TlsConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.ServerName,
+	}
+
+	if config.CaFile != "" {
+		caPem, err := os.ReadFile(config.CaFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading ca file")
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caPem) {
+			return nil, errors.Errorf("no valid certificates found in ca file [%s]", config.CaFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// NewSaslMechanism creates the sasl mechanism for authenticating against the brokers. Returns nil, if no mechanism
+// is configured. The credentials are read once to fail fast on missing files.
+func NewSaslMechanism(config SaslConfig) (sasl.Mechanism, error) {
+	mechanism := strings.ToUpper(config.Mechanism)
+	switch mechanism {
+	case "", SaslNone:
+		return nil, nil
+	case SaslOauthBearer:
+		_, err := secret(config.Token, config.TokenFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading token")
+		}
+		return oauth.Oauth(func(context.Context) (oauth.Auth, error) {
+			token, err := secret(config.Token, config.TokenFile)
+			return oauth.Auth{Token: token}, err
+		}), nil
+	case SaslPlain, SaslScramSha256, SaslScramSha512:
+		_, _, err := config.credentials()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unsupported sasl mechanism [%s]", config.Mechanism)
+	}
+
+	if mechanism == SaslPlain {
+		return plain.Plain(func(context.Context) (plain.Auth, error) {
+			user, pass, err := config.credentials()
+			return plain.Auth{User: user, Pass: pass}, err
+		}), nil
+	}
+
+	scramAuth := func(context.Context) (scram.Auth, error) {
+		user, pass, err := config.credentials()
+		return scram.Auth{User: user, Pass: pass}, err
+	}
+	if mechanism == SaslScramSha256 {
+		return scram.Sha256(scramAuth), nil
+	}
+	return scram.Sha512(scramAuth), nil
+}
+
+func (config SaslConfig) credentials() (string, string, error) {
+	user, err := secret(config.Username, config.UsernameFile)
+	if err != nil {
+		return "", "", errors.Wrap(err, "reading username")
+	}
+	pass, err := secret(config.Password, config.PasswordFile)
+	if err != nil {
+		return "", "", errors.Wrap(err, "reading password")
+	}
+	return user, pass, nil
+}
+
+func secret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading file [%s]", file)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
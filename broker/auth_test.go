@@ -0,0 +1,144 @@
+package broker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewSaslMechanism(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		expected  string
+	}{
+		{"PLAIN", "PLAIN"},
+		{"scram-sha-256", "SCRAM-SHA-256"},
+		{"SCRAM-SHA-512", "SCRAM-SHA-512"},
+		{"OAUTHBEARER", "OAUTHBEARER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			mechanism, err := NewSaslMechanism(SaslConfig{Mechanism: tt.mechanism, Username: "user", Password: "pass", Token: "token"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mechanism.Name())
+		})
+	}
+}
+
+func TestNewSaslMechanism_GivenNone_ThenNil(t *testing.T) {
+	mechanism, err := NewSaslMechanism(SaslConfig{Mechanism: SaslNone})
+	require.NoError(t, err)
+	assert.Nil(t, mechanism)
+
+	mechanism, err = NewSaslMechanism(SaslConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, mechanism)
+}
+
+func TestNewSaslMechanism_GivenUnknown_ThenError(t *testing.T) {
+	_, err := NewSaslMechanism(SaslConfig{Mechanism: "GSSAPI"})
+	assert.Error(t, err)
+}
+
+func TestNewSaslMechanism_GivenMissingFile_ThenError(t *testing.T) {
+	_, err := NewSaslMechanism(SaslConfig{Mechanism: SaslScramSha512, Username: "user", PasswordFile: "does-not-exist"})
+	assert.Error(t, err)
+
+	_, err = NewSaslMechanism(SaslConfig{Mechanism: SaslOauthBearer, TokenFile: "does-not-exist"})
+	assert.Error(t, err)
+}
+
+func TestSaslConfig_credentials_GivenFiles_ThenReadOnEveryCall(t *testing.T) {
+	tempDir := t.TempDir()
+	usernameFile := filepath.Join(tempDir, "username")
+	passwordFile := filepath.Join(tempDir, "password")
+	require.NoError(t, os.WriteFile(usernameFile, []byte("file-user\n"), 0600))
+	require.NoError(t, os.WriteFile(passwordFile, []byte("file-pass\n"), 0600))
+
+	config := SaslConfig{Username: "user", UsernameFile: usernameFile, Password: "pass", PasswordFile: passwordFile}
+	user, pass, err := config.credentials()
+	require.NoError(t, err)
+	assert.Equal(t, "file-user", user)
+	assert.Equal(t, "file-pass", pass)
+
+	require.NoError(t, os.WriteFile(passwordFile, []byte("rotated-pass"), 0600))
+	_, pass, err = config.credentials()
+	require.NoError(t, err)
+	assert.Equal(t, "rotated-pass", pass)
+}
+
+func TestNewSaslMechanism_GivenPlain_ThenAuthenticate(t *testing.T) {
+	mechanism, err := NewSaslMechanism(SaslConfig{Mechanism: SaslPlain, Username: "user", Password: "pass"})
+	require.NoError(t, err)
+
+	_, message, err := mechanism.Authenticate(context.Background(), "localhost:9092")
+	require.NoError(t, err)
+	assert.Equal(t, "\x00user\x00pass", string(message))
+}
+
+func TestNewTlsConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, tempDir)
+
+	tlsConfig, err := NewTlsConfig(TlsConfig{
+		CaFile:     certFile,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ServerName: "kafka.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "kafka.example.com", tlsConfig.ServerName)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+}
+
+func TestNewTlsConfig_GivenNoFiles_ThenUseDefaults(t *testing.T) {
+	tlsConfig, err := NewTlsConfig(TlsConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig.RootCAs)
+	assert.Empty(t, tlsConfig.Certificates)
+}
+
+func TestNewTlsConfig_GivenInvalidCa_ThenError(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("invalid"), 0600))
+
+	_, err := NewTlsConfig(TlsConfig{CaFile: caFile})
+	assert.Error(t, err)
+}
+
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka.example.com"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
@@ -0,0 +1,177 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TopicProvisioningNone     = "none"
+	TopicProvisioningValidate = "validate"
+	TopicProvisioningCreate   = "create"
+)
+
+const (
+	retentionConfig     = "retention.ms"
+	cleanupPolicyConfig = "cleanup.policy"
+)
+
+// TopicSpec is the expected configuration of the target topics. Negative or zero values mean broker default and are
+// not validated.
+type TopicSpec struct {
+	Partitions        int32
+	ReplicationFactor int16
+	Retention         time.Duration
+	CleanupPolicy     string
+}
+
+// TopicAdmin is the subset of the kafka admin client needed for provisioning topics.
+type TopicAdmin interface {
+	ListTopics(ctx context.Context, topics ...string) (kadm.TopicDetails, error)
+	DescribeTopicConfigs(ctx context.Context, topics ...string) (kadm.ResourceConfigs, error)
+	CreateTopics(ctx context.Context, partitions int32, replicationFactor int16, configs map[string]*string, topics ...string) (kadm.CreateTopicResponses, error)
+}
+
+// ProvisionTopics verifies that the topics exist and match the expected configuration. Depending on the provisioning
+// mode missing topics are created. Returns an error on missing topics (in validate mode) or configuration mismatch.
+func ProvisionTopics(ctx context.Context, admin TopicAdmin, mode string, spec TopicSpec, topics ...string) error {
+	switch mode {
+	case TopicProvisioningNone:
+		return nil
+	case TopicProvisioningValidate, TopicProvisioningCreate:
+	default:
+		return errors.Errorf("unknown topic provisioning mode [%s]", mode)
+	}
+
+	topics = uniqueTopics(topics)
+	details, err := admin.ListTopics(ctx, topics...)
+	if err != nil {
+		return errors.Wrap(err, "listing topics")
+	}
+
+	var missing, existing []string
+	for _, topic := range topics {
+		detail, ok := details[topic]
+		if !ok || errors.Is(detail.Err, kerr.UnknownTopicOrPartition) {
+			missing = append(missing, topic)
+		} else if detail.Err != nil {
+			return errors.Wrapf(detail.Err, "loading topic [%s]", topic)
+		} else {
+			existing = append(existing, topic)
+		}
+	}
+
+	if len(missing) > 0 {
+		if mode != TopicProvisioningCreate {
+			return errors.Errorf("missing topic(s) %v", missing)
+		}
+		err = createTopics(ctx, admin, spec, missing)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(existing) > 0 {
+		err = validateTopics(ctx, admin, spec, details, existing)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createTopics(ctx context.Context, admin TopicAdmin, spec TopicSpec, topics []string) error {
+	responses, err := admin.CreateTopics(ctx, spec.Partitions, spec.ReplicationFactor, spec.configs(), topics...)
+	if err != nil {
+		return errors.Wrap(err, "creating topics")
+	}
+	for _, topic := range topics {
+		response, ok := responses[topic]
+		if !ok {
+			return errors.Errorf("no create response for topic [%s]", topic)
+		}
+		if response.Err != nil {
+			return errors.Wrapf(response.Err, "creating topic [%s]: %s", topic, response.ErrMessage)
+		}
+		log.Printf("Created topic [%s].", topic)
+	}
+	return nil
+}
+
+func validateTopics(ctx context.Context, admin TopicAdmin, spec TopicSpec, details kadm.TopicDetails, topics []string) error {
+	var mismatches []string
+	for _, topic := range topics {
+		detail := details[topic]
+		partitions := int32(len(detail.Partitions))
+		if spec.Partitions > 0 && partitions != spec.Partitions {
+			mismatches = append(mismatches, fmt.Sprintf("topic [%s] has [%d] partitions, expected [%d]", topic, partitions, spec.Partitions))
+		}
+		replicationFactor := int16(len(detail.Partitions[0].Replicas))
+		if spec.ReplicationFactor > 0 && replicationFactor != spec.ReplicationFactor {
+			mismatches = append(mismatches, fmt.Sprintf("topic [%s] has replication factor [%d], expected [%d]", topic, replicationFactor, spec.ReplicationFactor))
+		}
+	}
+
+	expectedConfigs := spec.configs()
+	if len(expectedConfigs) > 0 {
+		resourceConfigs, err := admin.DescribeTopicConfigs(ctx, topics...)
+		if err != nil {
+			return errors.Wrap(err, "describing topic configs")
+		}
+		for _, resourceConfig := range resourceConfigs {
+			if resourceConfig.Err != nil {
+				return errors.Wrapf(resourceConfig.Err, "describing configs of topic [%s]", resourceConfig.Name)
+			}
+			for key, expected := range expectedConfigs {
+				actual := configValue(resourceConfig.Configs, key)
+				if actual != *expected {
+					mismatches = append(mismatches, fmt.Sprintf("topic [%s] has [%s=%s], expected [%s]", resourceConfig.Name, key, actual, *expected))
+				}
+			}
+		}
+	}
+
+	if len(mismatches) > 0 {
+		slices.Sort(mismatches)
+		return errors.Errorf("topic configuration mismatch: %s", strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+func (spec TopicSpec) configs() map[string]*string {
+	configs := map[string]*string{}
+	if spec.Retention > 0 {
+		configs[retentionConfig] = kadm.StringPtr(strconv.FormatInt(spec.Retention.Milliseconds(), 10))
+	}
+	if spec.CleanupPolicy != "" {
+		configs[cleanupPolicyConfig] = kadm.StringPtr(spec.CleanupPolicy)
+	}
+	return configs
+}
+
+func configValue(configs []kadm.Config, key string) string {
+	for _, config := range configs {
+		if config.Key == key {
+			return config.MaybeValue()
+		}
+	}
+	return ""
+}
+
+func uniqueTopics(topics []string) []string {
+	var unique []string
+	for _, topic := range topics {
+		if topic != "" && !slices.Contains(unique, topic) {
+			unique = append(unique, topic)
+		}
+	}
+	return unique
+}
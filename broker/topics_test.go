@@ -0,0 +1,137 @@
+package broker
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"testing"
+	"time"
+)
+
+type fakeTopic struct {
+	partitions        int32
+	replicationFactor int16
+	configs           map[string]string
+}
+
+type FakeTopicAdmin struct {
+	topics  map[string]fakeTopic
+	created []string
+}
+
+func (fta *FakeTopicAdmin) ListTopics(_ context.Context, topics ...string) (kadm.TopicDetails, error) {
+	details := kadm.TopicDetails{}
+	for _, topic := range topics {
+		existing, ok := fta.topics[topic]
+		if !ok {
+			details[topic] = kadm.TopicDetail{Topic: topic, Err: kerr.UnknownTopicOrPartition}
+			continue
+		}
+		partitions := kadm.PartitionDetails{}
+		for i := int32(0); i < existing.partitions; i++ {
+			partitions[i] = kadm.PartitionDetail{Topic: topic, Partition: i, Replicas: make([]int32, existing.replicationFactor)}
+		}
+		details[topic] = kadm.TopicDetail{Topic: topic, Partitions: partitions}
+	}
+	return details, nil
+}
+
+func (fta *FakeTopicAdmin) DescribeTopicConfigs(_ context.Context, topics ...string) (kadm.ResourceConfigs, error) {
+	var resourceConfigs kadm.ResourceConfigs
+	for _, topic := range topics {
+		var configs []kadm.Config
+		for key, value := range fta.topics[topic].configs {
+			configs = append(configs, kadm.Config{Key: key, Value: kadm.StringPtr(value)})
+		}
+		resourceConfigs = append(resourceConfigs, kadm.ResourceConfig{Name: topic, Configs: configs})
+	}
+	return resourceConfigs, nil
+}
+
+func (fta *FakeTopicAdmin) CreateTopics(_ context.Context, partitions int32, replicationFactor int16, configs map[string]*string, topics ...string) (kadm.CreateTopicResponses, error) {
+	responses := kadm.CreateTopicResponses{}
+	for _, topic := range topics {
+		topicConfigs := map[string]string{}
+		for key, value := range configs {
+			topicConfigs[key] = *value
+		}
+		fta.topics[topic] = fakeTopic{partitions: partitions, replicationFactor: replicationFactor, configs: topicConfigs}
+		fta.created = append(fta.created, topic)
+		responses[topic] = kadm.CreateTopicResponse{Topic: topic, NumPartitions: partitions, ReplicationFactor: replicationFactor}
+	}
+	return responses, nil
+}
+
+var testTopicSpec = TopicSpec{
+	Partitions:        6,
+	ReplicationFactor: 3,
+	Retention:         7 * 24 * time.Hour,
+	CleanupPolicy:     "delete",
+}
+
+func matchingTopic() fakeTopic {
+	return fakeTopic{
+		partitions:        6,
+		replicationFactor: 3,
+		configs:           map[string]string{"retention.ms": "604800000", "cleanup.policy": "delete"},
+	}
+}
+
+func TestProvisionTopics_GivenCreate_ThenCreateMissingTopics(t *testing.T) {
+	admin := &FakeTopicAdmin{topics: map[string]fakeTopic{"existing": matchingTopic()}}
+
+	err := ProvisionTopics(context.Background(), admin, TopicProvisioningCreate, testTopicSpec, "existing", "missing", "missing", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"missing"}, admin.created)
+	assert.Equal(t, matchingTopic(), admin.topics["missing"])
+}
+
+func TestProvisionTopics_GivenValidate_ThenFailOnMissingTopic(t *testing.T) {
+	admin := &FakeTopicAdmin{topics: map[string]fakeTopic{"existing": matchingTopic()}}
+
+	err := ProvisionTopics(context.Background(), admin, TopicProvisioningValidate, testTopicSpec, "existing", "missing")
+	require.ErrorContains(t, err, "missing")
+	assert.Empty(t, admin.created)
+
+	err = ProvisionTopics(context.Background(), admin, TopicProvisioningValidate, testTopicSpec, "existing")
+	require.NoError(t, err)
+}
+
+func TestProvisionTopics_GivenConfigurationMismatch_ThenError(t *testing.T) {
+	admin := &FakeTopicAdmin{topics: map[string]fakeTopic{
+		"existing": {
+			partitions:        1,
+			replicationFactor: 1,
+			configs:           map[string]string{"retention.ms": "1000", "cleanup.policy": "compact"},
+		},
+	}}
+
+	err := ProvisionTopics(context.Background(), admin, TopicProvisioningCreate, testTopicSpec, "existing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "topic [existing] has [1] partitions, expected [6]")
+	assert.Contains(t, err.Error(), "topic [existing] has replication factor [1], expected [3]")
+	assert.Contains(t, err.Error(), "topic [existing] has [retention.ms=1000], expected [604800000]")
+	assert.Contains(t, err.Error(), "topic [existing] has [cleanup.policy=compact], expected [delete]")
+}
+
+func TestProvisionTopics_GivenBrokerDefaults_ThenDoNotValidate(t *testing.T) {
+	admin := &FakeTopicAdmin{topics: map[string]fakeTopic{"existing": {partitions: 1, replicationFactor: 1}}}
+
+	err := ProvisionTopics(context.Background(), admin, TopicProvisioningValidate, TopicSpec{Partitions: -1, ReplicationFactor: -1}, "existing")
+	require.NoError(t, err)
+}
+
+func TestProvisionTopics_GivenNone_ThenDoNothing(t *testing.T) {
+	admin := &FakeTopicAdmin{topics: map[string]fakeTopic{}}
+
+	err := ProvisionTopics(context.Background(), admin, TopicProvisioningNone, testTopicSpec, "missing")
+	require.NoError(t, err)
+	assert.Empty(t, admin.created)
+}
+
+func TestProvisionTopics_GivenUnknownMode_ThenError(t *testing.T) {
+	err := ProvisionTopics(context.Background(), &FakeTopicAdmin{}, "foo", testTopicSpec, "topic")
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package identity
+
+import (
+	"encoding/binary"
+	"github.com/cloudflare/circl/xof/k12"
+	"github.com/pkg/errors"
+)
+
+// PublicKeyLength is the length of a public key (or digest) in bytes.
+const PublicKeyLength = 32
+
+// Length is the length of an identity string (56 characters public key and 4 characters checksum).
+const Length = 60
+
+// FromPublicKey encodes the public key as upper case identity string.
+func FromPublicKey(publicKey [PublicKeyLength]byte) string {
+	return encode(publicKey, 'A')
+}
+
+// ToPublicKey decodes the upper case identity string. Returns an error, if the identity is invalid.
+func ToPublicKey(identity string) ([PublicKeyLength]byte, error) {
+	return decode(identity, 'A')
+}
+
+// Validate returns an error, if the string is not a valid upper case identity (length, characters and checksum).
+func Validate(identity string) error {
+	_, err := ToPublicKey(identity)
+	return err
+}
+
+// FromDigest encodes the digest as lower case hash string, like used for transaction hashes.
+func FromDigest(digest [PublicKeyLength]byte) string {
+	return encode(digest, 'a')
+}
+
+// ToDigest decodes the lower case hash string. Returns an error, if the hash is invalid.
+func ToDigest(hash string) ([PublicKeyLength]byte, error) {
+	return decode(hash, 'a')
+}
+
+// encode encodes the data as four little endian uint64 fragments with 14 base 26 characters each, followed by the 4
+// characters checksum.
+func encode(data [PublicKeyLength]byte, letter byte) string {
+	var identity [Length]byte
+	for i := 0; i < 4; i++ {
+		fragment := binary.LittleEndian.Uint64(data[i*8 : (i+1)*8])
+		for j := 0; j < 14; j++ {
+			identity[i*14+j] = byte(fragment%26) + letter
+			fragment /= 26
+		}
+	}
+	sum := checksum(data)
+	for i := 0; i < 4; i++ {
+		identity[56+i] = byte(sum%26) + letter
+		sum /= 26
+	}
+	return string(identity[:])
+}
+
+func decode(identity string, letter byte) ([PublicKeyLength]byte, error) {
+	var data [PublicKeyLength]byte
+	if len(identity) != Length {
+		return data, errors.Errorf("invalid length [%d], expected [%d]", len(identity), Length)
+	}
+	for i := 0; i < 4; i++ {
+		var fragment uint64
+		for j := 13; j >= 0; j-- {
+			c := identity[i*14+j]
+			if c < letter || c >= letter+26 {
+				return data, errors.Errorf("invalid character [%c] at position [%d]", c, i*14+j)
+			}
+			fragment = fragment*26 + uint64(c-letter)
+		}
+		binary.LittleEndian.PutUint64(data[i*8:], fragment)
+	}
+	// re-encoding detects checksum mismatches and fragments that overflow uint64
+	if encode(data, letter) != identity {
+		return data, errors.Errorf("invalid checksum of [%s]", identity)
+	}
+	return data, nil
+}
+
+// checksum returns the lower 18 bits of the first three bytes of the KangarooTwelve hash of the data.
+func checksum(data [PublicKeyLength]byte) uint64 {
+	var hash [3]byte
+	state := k12.NewDraft10(nil)
+	_, _ = state.Write(data[:])
+	_, _ = state.Read(hash[:])
+	return (uint64(hash[0]) | uint64(hash[1])<<8 | uint64(hash[2])<<16) & 0x3FFFF
+}
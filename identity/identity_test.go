@@ -0,0 +1,81 @@
+package identity
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFromPublicKey(t *testing.T) {
+	var publicKey [PublicKeyLength]byte
+	assert.Equal(t, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAFXIB", FromPublicKey(publicKey))
+
+	publicKey[0] = 1 // QX contract
+	assert.Equal(t, "BAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARMID", FromPublicKey(publicKey))
+}
+
+func TestToPublicKey(t *testing.T) {
+	publicKey, err := ToPublicKey("BAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARMID")
+	require.NoError(t, err)
+	assert.Equal(t, [PublicKeyLength]byte{1}, publicKey)
+}
+
+func TestToPublicKey_GivenTransferIdentities_ThenRoundTrip(t *testing.T) {
+	tests := []struct {
+		identity  string
+		publicKey string
+	}{
+		{
+			identity:  "PJFKRWGTAAJVJGIYSBPJVEJQCVEAGNQBQKXTGPAKUDACMRKIYUNIRRNBDHSE",
+			publicKey: "8d7792c485969adb78e51ece64475f06c098c16dbbcd4b82ac8790fd8e9a8c34",
+		},
+		{
+			identity:  "KOTZUMYVOBZFNEMDIBRRJWORBBEBDTQFPHULAVLQXEJCTSVWRYPGYXHFFOBC",
+			publicKey: "4a27c6ff957b409b12d530f23bd5c82749d39d6c329707a98955449b906da7b6",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.identity, func(t *testing.T) {
+			publicKey, err := ToPublicKey(tt.identity)
+			require.NoError(t, err)
+			assert.Equal(t, tt.publicKey, hex.EncodeToString(publicKey[:]))
+			assert.Equal(t, tt.identity, FromPublicKey(publicKey))
+		})
+	}
+}
+
+func TestToDigest_GivenTransactionHash_ThenRoundTrip(t *testing.T) {
+	hash := "wjydyydyoltqlfdvnldtqqargoiamutsfqjnojyjhemhbrckrvxeyjodnfil"
+	digest, err := ToDigest(hash)
+	require.NoError(t, err)
+	assert.Equal(t, hash, FromDigest(digest))
+
+	_, err = ToPublicKey(hash)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity string
+		valid    bool
+	}{
+		{name: "valid", identity: "BAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARMID", valid: true},
+		{name: "wrong checksum", identity: "BAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARMIE"},
+		{name: "too short", identity: "BAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARMI"},
+		{name: "lower case", identity: "baaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaarmid"},
+		{name: "invalid character", identity: "B1AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARMID"},
+		{name: "fragment overflow", identity: "ZZZZZZZZZZZZZZAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.identity)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
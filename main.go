@@ -1,25 +1,34 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/ardanlabs/conf"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/qubic/go-events-publisher/broker"
 	"github.com/qubic/go-events-publisher/client"
 	"github.com/qubic/go-events-publisher/status"
 	"github.com/qubic/go-events-publisher/sync"
+	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sr"
 	"github.com/twmb/franz-go/plugin/kprom"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"syscall"
+	"time"
 )
 
 const envPrefix = "QUBIC_EVENTS_PUBLISHER"
 
+// version can be set at build time with -ldflags "-X main.version=v1.2.3". Falls back to the module build info.
+var version string
+
 func main() {
 	if err := run(); err != nil {
 		log.Fatalf("main: exited with error: %s", err.Error())
@@ -35,15 +44,71 @@ func run() error {
 			EventApiUrl string `conf:"default:localhost:8003"`
 		}
 		Broker struct {
-			BootstrapServers string `conf:"default:localhost:9092"`
-			MetricsPort      int    `conf:"default:9999"`
-			MetricsNamespace string `conf:"default:qubic-kafka"`
-			ProduceTopic     string `conf:"default:qubic-events"`
+			BootstrapServers       string   `conf:"default:localhost:9092"`
+			MetricsPort            int      `conf:"default:9999"`
+			MetricsNamespace       string   `conf:"default:qubic-kafka"`
+			ProduceTopic           string   `conf:"default:qubic-events"`
+			Transactional          bool     `conf:"default:false"`
+			TransactionalId        string   `conf:"default:qubic-events-publisher"`
+			KeyStrategy            string   `conf:"default:tick"`
+			Headers                []string `conf:"default:epoch;tick;eventId;eventType;transactionHash;schemaVersion;contentType;publisherVersion;source"`
+			TopicRoutes            map[uint32]string
+			TopicFormats           map[string]string
+			TickTopic              string
+			TickEventTypes         []uint32
+			TickRecordMaxBytes     int `conf:"default:900000"`
+			TransactionTopic       string
+			SchemaVersion          int `conf:"default:1"`
+			MigrationTopic         string
+			MigrationSchemaVersion int    `conf:"default:2"`
+			CloudEvents            string `conf:"default:none"`
+			CloudEventsSource      string
+			DecodePayloads         bool          `conf:"default:true"`
+			DeadLetterPolicy       string        `conf:"default:halt"`
+			DeadLetterTopic        string        `conf:"default:qubic-events-dead-letter"`
+			DigestPolicy           string        `conf:"default:none"`
+			DeliveryTimeout        time.Duration `conf:"default:30s"`
+			TickDeliveryTimeout    time.Duration `conf:"default:60s"`
+			TlsEnabled             bool          `conf:"default:false"`
+			TlsCaFile              string
+			TlsCertFile            string
+			TlsKeyFile             string
+			TlsServerName          string
+			SaslMechanism          string `conf:"default:NONE"`
+			SaslUsername           string
+			SaslUsernameFile       string
+			SaslPassword           string `conf:"mask"`
+			SaslPasswordFile       string
+			SaslToken              string `conf:"mask"`
+			SaslTokenFile          string
+			TickMarkerTopic        string
+			TopicProvisioning      string        `conf:"default:none"`
+			TopicPartitions        int32         `conf:"default:-1"`
+			TopicReplicationFactor int16         `conf:"default:-1"`
+			TopicRetention         time.Duration `conf:"default:0s"`
+			TopicCleanupPolicy     string
+		}
+		Registry struct {
+			Urls         []string
+			Username     string
+			Password     string `conf:"mask"`
+			AutoRegister bool   `conf:"default:true"`
+		}
+		Replay struct {
+			Epoch    uint32
+			FromTick uint32
+			ToTick   uint32
+			Topic    string
 		}
 		Sync struct {
-			InternalStoreFolder string `conf:"default:store"`
-			StartEpoch          uint32 `conf:"default:153"`
-			Enabled             bool   `conf:"default:true"`
+			InternalStoreFolder string        `conf:"default:store"`
+			StartEpoch          uint32        `conf:"default:153"`
+			PrefetchConcurrency int           `conf:"default:1"`
+			ShutdownGracePeriod time.Duration `conf:"default:30s"`
+			StopEpoch           uint32
+			StopTick            uint32
+			RunToCompletion     bool `conf:"default:false"`
+			Enabled             bool `conf:"default:true"`
 		}
 	}
 
@@ -74,35 +139,265 @@ func run() error {
 	}
 	log.Printf("main: Config :\n%v\n", out)
 
+	if cfg.Sync.StopTick > 0 && cfg.Sync.StopEpoch == 0 {
+		return errors.New("stop tick needs a stop epoch")
+	}
+	if cfg.Sync.StopEpoch > 0 && cfg.Sync.StopEpoch < cfg.Sync.StartEpoch {
+		return errors.Errorf("stop epoch [%d] is before start epoch [%d]", cfg.Sync.StopEpoch, cfg.Sync.StartEpoch)
+	}
+	if cfg.Sync.RunToCompletion && (cfg.Sync.StopEpoch == 0 || !cfg.Sync.Enabled) {
+		return errors.New("run to completion needs a stop epoch and enabled sync")
+	}
+
+	replay := cfg.Replay.Epoch > 0
+	if cfg.Broker.Transactional && !replay && cfg.Broker.TickMarkerTopic == "" {
+		return errors.New("transactional publishing needs a tick marker topic")
+	}
+	if replay {
+		cfg.Broker.TransactionalId += "-replay" // don't fence the live publisher
+		if cfg.Replay.Topic != "" {
+			// publish the events to the target topic only
+			cfg.Broker.ProduceTopic = cfg.Replay.Topic
+			cfg.Broker.TopicRoutes = nil
+			cfg.Broker.MigrationTopic = ""
+			cfg.Broker.TransactionTopic = ""
+			cfg.Broker.TickTopic = ""
+			cfg.Broker.TickMarkerTopic = ""
+		}
+	}
+
 	eventClient, err := client.NewIntegrationEventClient(cfg.Client.EventApiUrl)
 	if err != nil {
 		return errors.Wrap(err, "creating event client")
 	}
 
-	store, err := sync.NewPebbleStore(cfg.Sync.InternalStoreFolder)
-	if err != nil {
-		return errors.Wrap(err, "creating db")
+	var store *sync.PebbleStore
+	if !replay { // replay does not use the checkpoints
+		store, err = sync.NewPebbleStore(cfg.Sync.InternalStoreFolder)
+		if err != nil {
+			return errors.Wrap(err, "creating db")
+		}
 	}
 
 	m := kprom.NewMetrics(cfg.Broker.MetricsNamespace,
 		kprom.Registerer(prometheus.DefaultRegisterer),
 		kprom.Gatherer(prometheus.DefaultGatherer))
-	kcl, err := kgo.NewClient(
+	connectionOpts := []kgo.Opt{
+		kgo.SeedBrokers(cfg.Broker.BootstrapServers),
+	}
+	if cfg.Broker.TlsEnabled {
+		tlsConfig, err := broker.NewTlsConfig(broker.TlsConfig{
+			CaFile:     cfg.Broker.TlsCaFile,
+			CertFile:   cfg.Broker.TlsCertFile,
+			KeyFile:    cfg.Broker.TlsKeyFile,
+			ServerName: cfg.Broker.TlsServerName,
+		})
+		if err != nil {
+			return errors.Wrap(err, "creating tls config")
+		}
+		connectionOpts = append(connectionOpts, kgo.DialTLSConfig(tlsConfig))
+	}
+	saslMechanism, err := broker.NewSaslMechanism(broker.SaslConfig{
+		Mechanism:    cfg.Broker.SaslMechanism,
+		Username:     cfg.Broker.SaslUsername,
+		UsernameFile: cfg.Broker.SaslUsernameFile,
+		Password:     cfg.Broker.SaslPassword,
+		PasswordFile: cfg.Broker.SaslPasswordFile,
+		Token:        cfg.Broker.SaslToken,
+		TokenFile:    cfg.Broker.SaslTokenFile,
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating sasl mechanism")
+	}
+	if saslMechanism != nil {
+		log.Printf("main: Authenticating with sasl mechanism [%s].", saslMechanism.Name())
+		connectionOpts = append(connectionOpts, kgo.SASL(saslMechanism))
+	}
+	kafkaOpts := append([]kgo.Opt{
 		kgo.WithHooks(m),
 		kgo.DefaultProduceTopic(cfg.Broker.ProduceTopic),
-		kgo.SeedBrokers(cfg.Broker.BootstrapServers),
 		kgo.ProducerBatchCompression(kgo.ZstdCompression()),
-	)
+		kgo.RecordDeliveryTimeout(cfg.Broker.DeliveryTimeout),
+	}, connectionOpts...)
+	if cfg.Broker.Transactional {
+		kafkaOpts = append(kafkaOpts, kgo.TransactionalID(cfg.Broker.TransactionalId))
+	}
+	kcl, err := kgo.NewClient(kafkaOpts...)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer kcl.Close()
 
-	eventProcessor := sync.NewEventProducer(kcl)
-	eventReader := sync.NewEventProcessor(eventClient, eventProcessor, store, sync.NewMetrics(cfg.Broker.MetricsNamespace))
-	if cfg.Sync.Enabled {
-		go eventReader.SyncInLoop(cfg.Sync.StartEpoch)
+	keyStrategy, err := sync.NewKeyStrategy(cfg.Broker.KeyStrategy)
+	if err != nil {
+		return errors.Wrap(err, "creating key strategy")
+	}
+
+	headers, err := sync.NewRecordHeaders(cfg.Broker.Headers, publisherVersion(), cfg.Client.EventApiUrl)
+	if err != nil {
+		return errors.Wrap(err, "creating record headers")
+	}
+
+	var schemaRegistry sync.SchemaRegistry
+	if len(cfg.Registry.Urls) > 0 {
+		registryOpts := []sr.ClientOpt{sr.URLs(cfg.Registry.Urls...)}
+		if cfg.Registry.Username != "" {
+			registryOpts = append(registryOpts, sr.BasicAuth(cfg.Registry.Username, cfg.Registry.Password))
+		}
+		registryClient, err := sr.NewClient(registryOpts...)
+		if err != nil {
+			return errors.Wrap(err, "creating schema registry client")
+		}
+		schemaRegistry = registryClient
+	}
+
+	registryCtx, registryCancel := context.WithTimeout(context.Background(), time.Minute)
+	serializers, err := sync.NewSerializers(registryCtx, cfg.Broker.TopicFormats, cfg.Broker.SchemaVersion, schemaRegistry, cfg.Registry.AutoRegister)
+	registryCancel()
+	if err != nil {
+		return errors.Wrap(err, "creating serializers")
+	}
+
+	tickAggregation, err := sync.NewTickAggregation(cfg.Broker.TickTopic, cfg.Broker.TickEventTypes, cfg.Broker.TickRecordMaxBytes)
+	if err != nil {
+		return errors.Wrap(err, "creating tick aggregation")
+	}
+	if format, ok := cfg.Broker.TopicFormats[cfg.Broker.TickTopic]; ok && tickAggregation != nil && format != sync.FormatJson {
+		return errors.Errorf("tick topic [%s] only supports json format", cfg.Broker.TickTopic)
+	}
+
+	cloudEventsSource := cfg.Broker.CloudEventsSource
+	if cloudEventsSource == "" {
+		cloudEventsSource = sync.CloudEventsSource(cfg.Client.EventApiUrl)
+	}
+	cloudEvents, err := sync.NewCloudEvents(cfg.Broker.CloudEvents, cloudEventsSource)
+	if err != nil {
+		return errors.Wrap(err, "creating cloud events")
+	}
+	err = cloudEvents.CheckFormats(cfg.Broker.TopicFormats)
+	if err != nil {
+		return errors.Wrap(err, "checking cloud events")
+	}
+
+	syncMetrics := sync.NewMetrics(cfg.Broker.MetricsNamespace)
+	serviceStatus := status.NewStatus()
+
+	producerOpts := []sync.ProducerOption{
+		sync.WithKeyStrategy(keyStrategy),
+		sync.WithRecordHeaders(headers),
+		sync.WithTopicRouter(sync.NewTopicRouter(cfg.Broker.ProduceTopic, cfg.Broker.TopicRoutes)),
+		sync.WithSerializers(serializers),
+		sync.WithCloudEvents(cloudEvents),
+		sync.WithTickDeliveryTimeout(cfg.Broker.TickDeliveryTimeout),
+	}
+	topics := []string{cfg.Broker.ProduceTopic}
+	for _, topic := range cfg.Broker.TopicRoutes {
+		topics = append(topics, topic)
+	}
+	if cfg.Broker.MigrationTopic != "" {
+		migrationSerializer, err := sync.NewSerializer(sync.FormatJson, cfg.Broker.MigrationSchemaVersion)
+		if err != nil {
+			return errors.Wrap(err, "creating migration serializer")
+		}
+		log.Printf("main: Publishing schema version [%d] to migration topic [%s].", cfg.Broker.MigrationSchemaVersion, cfg.Broker.MigrationTopic)
+		producerOpts = append(producerOpts, sync.WithMigrationTopic(cfg.Broker.MigrationTopic, migrationSerializer))
+		topics = append(topics, cfg.Broker.MigrationTopic)
+	}
+	if tickAggregation != nil {
+		log.Printf("main: Publishing tick records of event types [%v] to topic [%s].", cfg.Broker.TickEventTypes, cfg.Broker.TickTopic)
+		producerOpts = append(producerOpts, sync.WithTickAggregation(tickAggregation))
+		topics = append(topics, cfg.Broker.TickTopic)
+	}
+	if cfg.Broker.TransactionTopic != "" {
+		log.Printf("main: Publishing transaction messages to topic [%s].", cfg.Broker.TransactionTopic)
+		producerOpts = append(producerOpts, sync.WithTransactionTopic(cfg.Broker.TransactionTopic))
+		topics = append(topics, cfg.Broker.TransactionTopic)
+	}
+	if cfg.Broker.DecodePayloads {
+		producerOpts = append(producerOpts, sync.WithPayloadDecoding(syncMetrics))
+	}
+	if cfg.Broker.TickMarkerTopic != "" {
+		log.Printf("main: Publishing tick markers to topic [%s].", cfg.Broker.TickMarkerTopic)
+		producerOpts = append(producerOpts, sync.WithTickMarkers(cfg.Broker.TickMarkerTopic))
+		topics = append(topics, cfg.Broker.TickMarkerTopic)
+	}
+	var deadLetters *sync.DeadLetterQueue
+	if cfg.Broker.DeadLetterPolicy == sync.PolicyDeadLetter || cfg.Broker.DigestPolicy == sync.PolicyDeadLetter {
+		deadLetters = sync.NewDeadLetterQueue(cfg.Broker.DeadLetterTopic, syncMetrics)
+		topics = append(topics, cfg.Broker.DeadLetterTopic)
+	}
+	switch cfg.Broker.DeadLetterPolicy {
+	case sync.PolicyHalt:
+	case sync.PolicyDeadLetter:
+		log.Printf("main: Sending unpublishable events to dead letter topic [%s].", cfg.Broker.DeadLetterTopic)
+		producerOpts = append(producerOpts, sync.WithDeadLetterQueue(deadLetters))
+	default:
+		return errors.Errorf("unknown dead letter policy [%s]", cfg.Broker.DeadLetterPolicy)
+	}
+	digestVerifier, err := sync.NewDigestVerifier(cfg.Broker.DigestPolicy, deadLetters, syncMetrics)
+	if err != nil {
+		return errors.Wrap(err, "creating digest verifier")
+	}
+	if digestVerifier != nil {
+		log.Printf("main: Verifying event digests with policy [%s].", cfg.Broker.DigestPolicy)
+		producerOpts = append(producerOpts, sync.WithDigestVerifier(digestVerifier))
+	}
+	if replay {
+		producerOpts = append(producerOpts, sync.WithReplayHeader())
+	}
+
+	adminCtx, adminCancel := context.WithTimeout(context.Background(), time.Minute)
+	err = broker.ProvisionTopics(adminCtx, kadm.NewClient(kcl), cfg.Broker.TopicProvisioning, broker.TopicSpec{
+		Partitions:        cfg.Broker.TopicPartitions,
+		ReplicationFactor: cfg.Broker.TopicReplicationFactor,
+		Retention:         cfg.Broker.TopicRetention,
+		CleanupPolicy:     cfg.Broker.TopicCleanupPolicy,
+	}, topics...)
+	adminCancel()
+	if err != nil {
+		return errors.Wrap(err, "provisioning topics")
+	}
+
+	var eventProcessor sync.Producer = sync.NewEventProducer(kcl, producerOpts...)
+	if cfg.Broker.Transactional {
+		log.Printf("main: Publishing ticks transactionally with id [%s].", cfg.Broker.TransactionalId)
+		eventProcessor = sync.NewTransactionalEventProducer(kcl, eventProcessor)
+	}
+	if replay {
+		return replayTicks(cfg.Replay.Epoch, cfg.Replay.FromTick, cfg.Replay.ToTick, sync.NewReplayer(eventClient, eventProcessor, cfg.Sync.PrefetchConcurrency), kcl)
+	}
+	if cfg.Broker.Transactional && cfg.Sync.Enabled {
+		// the last processed tick is stored after the commit. The committed tick marker is the checkpoint.
+		restoreCtx, restoreCancel := context.WithTimeout(context.Background(), time.Minute)
+		err = sync.RestoreCheckpoint(restoreCtx, sync.NewKafkaTickMarkerReader(kadm.NewClient(kcl), cfg.Broker.TickMarkerTopic, connectionOpts...), store)
+		restoreCancel()
+		if err != nil {
+			return errors.Wrap(err, "restoring checkpoint")
+		}
+	}
+	eventReader := sync.NewEventProcessor(eventClient, eventProcessor, store, syncMetrics,
+		sync.WithStatus(serviceStatus),
+		sync.WithPrefetch(cfg.Sync.PrefetchConcurrency),
+		sync.WithShutdownGracePeriod(drainPeriod(cfg.Sync.ShutdownGracePeriod)),
+		sync.WithStop(cfg.Sync.StopEpoch, cfg.Sync.StopTick),
+	)
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	defer stopSync()
+	syncDone := make(chan struct{})
+	completed := make(chan error, 1)
+	if cfg.Sync.RunToCompletion {
+		log.Printf("main: Running to completion up to epoch [%d] tick [%d].", cfg.Sync.StopEpoch, cfg.Sync.StopTick)
+		go func() {
+			defer close(syncDone)
+			completed <- eventReader.SyncToCompletion(syncCtx, cfg.Sync.StartEpoch)
+		}()
+	} else if cfg.Sync.Enabled {
+		go func() {
+			defer close(syncDone)
+			eventReader.SyncInLoop(syncCtx, cfg.Sync.StartEpoch)
+		}()
 	} else {
+		close(syncDone)
 		log.Println("main: Event processing disabled")
 	}
 
@@ -112,7 +407,7 @@ func run() error {
 	// metrics endpoint
 	go func() {
 		log.Printf("main: Starting status and metrics endpoint on port [%d].", cfg.Broker.MetricsPort)
-		http.Handle("/status", &status.Handler{})
+		http.Handle("/status", &status.Handler{Status: serviceStatus})
 		http.Handle("/metrics", promhttp.Handler())
 		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", cfg.Broker.MetricsPort), nil))
 	}()
@@ -122,9 +417,92 @@ func run() error {
 	for {
 		select {
 		case <-shutdown:
-			log.Println("main: Received shutdown signal, shutting down...")
-			return nil
+			log.Printf("main: Received shutdown signal, shutting down with grace period [%v]...", cfg.Sync.ShutdownGracePeriod)
+			shutdownErr := shutdownGracefully(cfg.Sync.ShutdownGracePeriod, stopSync, syncDone, kcl, store)
+			if !cfg.Sync.RunToCompletion {
+				return shutdownErr
+			}
+			select {
+			case err := <-completed: // sent before sync is done
+				if err != nil {
+					return errors.Wrap(err, "running to completion")
+				}
+			default:
+				return errors.New("running to completion: stopped before completion")
+			}
+			return shutdownErr
+		case err := <-completed:
+			shutdownErr := shutdownGracefully(cfg.Sync.ShutdownGracePeriod, stopSync, syncDone, kcl, store)
+			if err != nil {
+				return errors.Wrap(err, "running to completion")
+			}
+			return shutdownErr
+		}
+	}
+
+}
+
+func publisherVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// drainPeriod is the part of the grace period the current tick has for delivery. It is shorter than the sync wait in
+// shutdownGracefully, so that an undelivered tick is aborted before the shutdown gives up waiting.
+func drainPeriod(gracePeriod time.Duration) time.Duration {
+	return gracePeriod / 2
+}
+
+// shutdownGracefully stops fetching new ticks, waits for the current tick to be delivered, flushes the kafka client and
+// closes the store within the grace period. Waiting for the current tick may take up to three quarters of the grace
+// period, flushing gets the rest. The checkpoint is persisted after every delivered tick. The store is closed on all
+// paths, once the sync is done. If the current tick is not completed in time, the store stays open, as the sync may
+// still write the checkpoint.
+func shutdownGracefully(gracePeriod time.Duration, stopSync context.CancelFunc, syncDone <-chan struct{}, kcl *kgo.Client, store *sync.PebbleStore) (err error) {
+	deadline := time.Now().Add(gracePeriod)
+
+	syncWait := gracePeriod * 3 / 4
+	stopSync()
+	select {
+	case <-syncDone:
+	case <-time.After(syncWait):
+		return errors.Errorf("current tick not completed within [%v], store not closed", syncWait)
+	}
+	defer func() {
+		closeErr := store.Close()
+		if closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "closing store")
 		}
+	}()
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	err = kcl.Flush(ctx)
+	if err != nil {
+		return errors.Wrap(err, "flushing kafka client")
 	}
+	log.Println("main: Shutdown complete.")
+	return nil
+}
+
+// replayTicks publishes the tick range again and flushes the kafka client. Stops on shutdown signal.
+func replayTicks(epoch, fromTick, toTick uint32, replayer *sync.Replayer, kcl *kgo.Client) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	count, err := replayer.Replay(ctx, epoch, fromTick, toTick)
+	if err != nil {
+		return errors.Wrapf(err, "replaying ticks [%d] to [%d] of epoch [%d]", fromTick, toTick, epoch)
+	}
+	err = kcl.Flush(ctx)
+	if err != nil {
+		return errors.Wrap(err, "flushing kafka client")
+	}
+	log.Printf("main: Replayed [%d] events of ticks [%d] to [%d] of epoch [%d].", count, fromTick, toTick, epoch)
+	return nil
 }
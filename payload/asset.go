@@ -0,0 +1,132 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// AssetIssuance is the payload of an asset issuance event.
+type AssetIssuance struct {
+	Issuer                string `json:"issuer"`
+	NumberOfShares        int64  `json:"numberOfShares"`
+	ManagingContractIndex int64  `json:"managingContractIndex"`
+	Name                  string `json:"name"`
+	NumberOfDecimalPlaces int8   `json:"numberOfDecimalPlaces"`
+	UnitOfMeasurement     string `json:"unitOfMeasurement"`
+}
+
+// AssetChange is the payload of an asset ownership or possession change event. Source and destination are the old
+// and new owner (or possessor).
+type AssetChange struct {
+	Source                string `json:"source"`
+	Destination           string `json:"destination"`
+	Issuer                string `json:"issuer"`
+	NumberOfShares        int64  `json:"numberOfShares"`
+	ManagingContractIndex int64  `json:"managingContractIndex"`
+	Name                  string `json:"name"`
+	NumberOfDecimalPlaces int8   `json:"numberOfDecimalPlaces"`
+	UnitOfMeasurement     string `json:"unitOfMeasurement"`
+}
+
+// AssetOwnershipManagingContractChange is the payload of an event that changes the contract managing the ownership.
+type AssetOwnershipManagingContractChange struct {
+	Owner                    string `json:"owner"`
+	Issuer                   string `json:"issuer"`
+	SourceContractIndex      uint32 `json:"sourceContractIndex"`
+	DestinationContractIndex uint32 `json:"destinationContractIndex"`
+	NumberOfShares           int64  `json:"numberOfShares"`
+	Name                     string `json:"name"`
+}
+
+// AssetPossessionManagingContractChange is the payload of an event that changes the contract managing the possession.
+type AssetPossessionManagingContractChange struct {
+	Possessor                string `json:"possessor"`
+	Owner                    string `json:"owner"`
+	Issuer                   string `json:"issuer"`
+	SourceContractIndex      uint32 `json:"sourceContractIndex"`
+	DestinationContractIndex uint32 `json:"destinationContractIndex"`
+	NumberOfShares           int64  `json:"numberOfShares"`
+	Name                     string `json:"name"`
+}
+
+// decodeAssetIssuance decodes issuer public key (32 bytes), number of shares (int64), managing contract index (int64),
+// name (7 bytes), number of decimal places (1 byte) and unit of measurement (7 bytes).
+func decodeAssetIssuance(data []byte) (any, error) {
+	if err := checkLength(data, 63); err != nil {
+		return nil, err
+	}
+	return AssetIssuance{
+		Issuer:                readIdentity(data[0:32]),
+		NumberOfShares:        int64(binary.LittleEndian.Uint64(data[32:40])),
+		ManagingContractIndex: int64(binary.LittleEndian.Uint64(data[40:48])),
+		Name:                  readAssetName(data[48:55]),
+		NumberOfDecimalPlaces: int8(data[55]),
+		UnitOfMeasurement:     readUnitOfMeasurement(data[56:63]),
+	}, nil
+}
+
+// decodeAssetChange decodes source, destination and issuer public key (32 bytes each), followed by the asset fields
+// like in the asset issuance.
+func decodeAssetChange(data []byte) (any, error) {
+	if err := checkLength(data, 127); err != nil {
+		return nil, err
+	}
+	return AssetChange{
+		Source:                readIdentity(data[0:32]),
+		Destination:           readIdentity(data[32:64]),
+		Issuer:                readIdentity(data[64:96]),
+		NumberOfShares:        int64(binary.LittleEndian.Uint64(data[96:104])),
+		ManagingContractIndex: int64(binary.LittleEndian.Uint64(data[104:112])),
+		Name:                  readAssetName(data[112:119]),
+		NumberOfDecimalPlaces: int8(data[119]),
+		UnitOfMeasurement:     readUnitOfMeasurement(data[120:127]),
+	}, nil
+}
+
+// decodeAssetOwnershipManagingContractChange decodes owner and issuer public key (32 bytes each), source and destination
+// contract index (uint32 each), number of shares (int64) and name (7 bytes).
+func decodeAssetOwnershipManagingContractChange(data []byte) (any, error) {
+	if err := checkLength(data, 87); err != nil {
+		return nil, err
+	}
+	return AssetOwnershipManagingContractChange{
+		Owner:                    readIdentity(data[0:32]),
+		Issuer:                   readIdentity(data[32:64]),
+		SourceContractIndex:      binary.LittleEndian.Uint32(data[64:68]),
+		DestinationContractIndex: binary.LittleEndian.Uint32(data[68:72]),
+		NumberOfShares:           int64(binary.LittleEndian.Uint64(data[72:80])),
+		Name:                     readAssetName(data[80:87]),
+	}, nil
+}
+
+// decodeAssetPossessionManagingContractChange decodes possessor, owner and issuer public key (32 bytes each), source and
+// destination contract index (uint32 each), number of shares (int64) and name (7 bytes).
+func decodeAssetPossessionManagingContractChange(data []byte) (any, error) {
+	if err := checkLength(data, 119); err != nil {
+		return nil, err
+	}
+	return AssetPossessionManagingContractChange{
+		Possessor:                readIdentity(data[0:32]),
+		Owner:                    readIdentity(data[32:64]),
+		Issuer:                   readIdentity(data[64:96]),
+		SourceContractIndex:      binary.LittleEndian.Uint32(data[96:100]),
+		DestinationContractIndex: binary.LittleEndian.Uint32(data[100:104]),
+		NumberOfShares:           int64(binary.LittleEndian.Uint64(data[104:112])),
+		Name:                     readAssetName(data[112:119]),
+	}, nil
+}
+
+// readAssetName reads the zero padded asset name.
+func readAssetName(data []byte) string {
+	return string(bytes.TrimRight(data, "\x00"))
+}
+
+// readUnitOfMeasurement reads the unit of measurement. Every byte is an exponent of a base unit and is returned as
+// digit (like the qubic cli).
+func readUnitOfMeasurement(data []byte) string {
+	unit := make([]byte, len(data))
+	for i, b := range data {
+		unit[i] = b + '0'
+	}
+	return string(unit)
+}
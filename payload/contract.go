@@ -0,0 +1,84 @@
+package payload
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+)
+
+// ContractMessage is the payload of a contract error, warning, information or debug message.
+type ContractMessage struct {
+	ContractIndex uint32 `json:"contractIndex"`
+	Type          uint32 `json:"type"`
+	Body          []byte `json:"body"`              // raw message body (base64 encoded in json)
+	Content       any    `json:"content,omitempty"` // decoded body, if there is a registered decoder
+}
+
+// CustomMessage is the payload of a custom message.
+type CustomMessage struct {
+	Type    uint64 `json:"type"`
+	Body    []byte `json:"body"`              // raw message body (base64 encoded in json)
+	Content any    `json:"content,omitempty"` // decoded body, if there is a registered decoder
+}
+
+type contractMessageKey struct {
+	contractIndex uint32
+	logType       uint32
+}
+
+var (
+	contractDecoders = map[contractMessageKey]Decoder{}
+	customDecoders   = map[uint64]Decoder{}
+)
+
+// RegisterContractDecoder registers a decoder for the body of the contract messages with the given contract index and
+// log type. Needs to be called during initialization (not thread safe).
+func RegisterContractDecoder(contractIndex, logType uint32, decoder Decoder) {
+	contractDecoders[contractMessageKey{contractIndex: contractIndex, logType: logType}] = decoder
+}
+
+// RegisterCustomDecoder registers a decoder for the body of the custom messages with the given type. Needs to be
+// called during initialization (not thread safe).
+func RegisterCustomDecoder(customType uint64, decoder Decoder) {
+	customDecoders[customType] = decoder
+}
+
+// decodeContractMessage decodes contract index (uint32) and log type (uint32). The rest is the message body.
+func decodeContractMessage(data []byte) (any, error) {
+	if err := checkLength(data, 8); err != nil {
+		return nil, err
+	}
+	message := ContractMessage{
+		ContractIndex: binary.LittleEndian.Uint32(data[0:4]),
+		Type:          binary.LittleEndian.Uint32(data[4:8]),
+		Body:          data[8:],
+	}
+	decoder, ok := contractDecoders[contractMessageKey{contractIndex: message.ContractIndex, logType: message.Type}]
+	if ok {
+		content, err := decoder(message.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding message type [%d] of contract [%d]", message.Type, message.ContractIndex)
+		}
+		message.Content = content
+	}
+	return message, nil
+}
+
+// decodeCustomMessage decodes the custom message type (uint64). The rest is the message body.
+func decodeCustomMessage(data []byte) (any, error) {
+	if err := checkLength(data, 8); err != nil {
+		return nil, err
+	}
+	message := CustomMessage{
+		Type: binary.LittleEndian.Uint64(data[0:8]),
+		Body: data[8:],
+	}
+	decoder, ok := customDecoders[message.Type]
+	if ok {
+		content, err := decoder(message.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding custom message type [%d]", message.Type)
+		}
+		message.Content = content
+	}
+	return message, nil
+}
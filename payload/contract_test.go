@@ -0,0 +1,63 @@
+package payload
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testContractLog struct {
+	Amount int64 `json:"amount"`
+}
+
+func decodeTestContractLog(data []byte) (any, error) {
+	if err := checkLength(data, 8); err != nil {
+		return nil, err
+	}
+	return testContractLog{Amount: int64(binary.LittleEndian.Uint64(data))}, nil
+}
+
+func TestDecode_GivenRegisteredContractDecoder_ThenDecodeBody(t *testing.T) {
+	RegisterContractDecoder(2, 5, decodeTestContractLog)
+	t.Cleanup(func() { delete(contractDecoders, contractMessageKey{contractIndex: 2, logType: 5}) })
+
+	data := []byte{2, 0, 0, 0, 5, 0, 0, 0, 100, 0, 0, 0, 0, 0, 0, 0}
+	decoded, err := Decode(TypeContractWarningMessage, data)
+	require.NoError(t, err)
+	assert.Equal(t, ContractMessage{
+		ContractIndex: 2,
+		Type:          5,
+		Body:          data[8:],
+		Content:       testContractLog{Amount: 100},
+	}, decoded)
+
+	// other contract and type are not decoded
+	data[4] = 6
+	decoded, err = Decode(TypeContractWarningMessage, data)
+	require.NoError(t, err)
+	assert.Nil(t, decoded.(ContractMessage).Content)
+}
+
+func TestDecode_GivenRegisteredCustomDecoder_ThenDecodeBody(t *testing.T) {
+	RegisterCustomDecoder(42, decodeTestContractLog)
+	t.Cleanup(func() { delete(customDecoders, 42) })
+
+	data := []byte{42, 0, 0, 0, 0, 0, 0, 0, 100, 0, 0, 0, 0, 0, 0, 0}
+	decoded, err := Decode(TypeCustomMessage, data)
+	require.NoError(t, err)
+	assert.Equal(t, CustomMessage{
+		Type:    42,
+		Body:    data[8:],
+		Content: testContractLog{Amount: 100},
+	}, decoded)
+}
+
+func TestDecode_GivenFailingContractDecoder_ThenError(t *testing.T) {
+	RegisterContractDecoder(2, 5, func([]byte) (any, error) { return nil, errors.New("test error") })
+	t.Cleanup(func() { delete(contractDecoders, contractMessageKey{contractIndex: 2, logType: 5}) })
+
+	_, err := Decode(TypeContractErrorMessage, []byte{2, 0, 0, 0, 5, 0, 0, 0})
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package payload
+
+import (
+	"github.com/pkg/errors"
+	"github.com/qubic/go-events-publisher/identity"
+)
+
+const (
+	TypeQuTransfer                            uint32 = 0
+	TypeAssetIssuance                         uint32 = 1
+	TypeAssetOwnershipChange                  uint32 = 2
+	TypeAssetPossessionChange                 uint32 = 3
+	TypeContractErrorMessage                  uint32 = 4
+	TypeContractWarningMessage                uint32 = 5
+	TypeContractInformationMessage            uint32 = 6
+	TypeContractDebugMessage                  uint32 = 7
+	TypeBurning                               uint32 = 8
+	TypeDustBurning                           uint32 = 9
+	TypeSpectrumStats                         uint32 = 10
+	TypeAssetOwnershipManagingContractChange  uint32 = 11
+	TypeAssetPossessionManagingContractChange uint32 = 12
+	TypeCustomMessage                         uint32 = 255
+)
+
+// Decoder decodes the raw event data of one event type.
+type Decoder func(data []byte) (any, error)
+
+var decoders = map[uint32]Decoder{
+	TypeQuTransfer:                            decodeQuTransfer,
+	TypeAssetIssuance:                         decodeAssetIssuance,
+	TypeAssetOwnershipChange:                  decodeAssetChange,
+	TypeAssetPossessionChange:                 decodeAssetChange,
+	TypeContractErrorMessage:                  decodeContractMessage,
+	TypeContractWarningMessage:                decodeContractMessage,
+	TypeContractInformationMessage:            decodeContractMessage,
+	TypeContractDebugMessage:                  decodeContractMessage,
+	TypeBurning:                               decodeBurning,
+	TypeDustBurning:                           decodeDustBurning,
+	TypeSpectrumStats:                         decodeSpectrumStats,
+	TypeAssetOwnershipManagingContractChange:  decodeAssetOwnershipManagingContractChange,
+	TypeAssetPossessionManagingContractChange: decodeAssetPossessionManagingContractChange,
+	TypeCustomMessage:                         decodeCustomMessage,
+}
+
+// Decode decodes the raw event data into typed fields. Returns nil, if there is no decoder for the event type.
+func Decode(eventType uint32, data []byte) (any, error) {
+	decoder, ok := decoders[eventType]
+	if !ok {
+		return nil, nil
+	}
+	decoded, err := decoder(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decoding event type [%d]", eventType)
+	}
+	return decoded, nil
+}
+
+func checkLength(data []byte, expected int) error {
+	if len(data) < expected {
+		return errors.Errorf("invalid data length [%d], expected [%d]", len(data), expected)
+	}
+	return nil
+}
+
+func readIdentity(data []byte) string {
+	return identity.FromPublicKey([identity.PublicKeyLength]byte(data[:identity.PublicKeyLength]))
+}
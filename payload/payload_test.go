@@ -0,0 +1,137 @@
+package payload
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// real events from the event service and synthetic fixtures
+var goldenTests = []struct {
+	name      string
+	eventType uint32
+	eventData string
+}{
+	{
+		name:      "qu_transfer",
+		eventType: TypeQuTransfer,
+		eventData: "jXeSxIWWmtt45R7OZEdfBsCYwW27zUuCrIeQ/Y6ajDRKJ8b/lXtAmxLVMPI71cgnSdOdbDKXB6mJVUSbkG2ntgEAAAAAAAAA", // epoch 153, tick 21679416, event 13857
+	},
+	{
+		name:      "asset_issuance",
+		eventType: TypeAssetIssuance,
+		eventData: "U1xvjrUR9dlmobByXfkuvydRT6upRcu9aY4jrHLEF1dAQg8AAAAAAAEAAAAAAAAAUUZUAAAAAAAAAAAAAAAA",
+	},
+	{
+		name:      "asset_ownership_change",
+		eventType: TypeAssetOwnershipChange,
+		eventData: "Qc9nlLpCALg5xTUxVV8POZjfTLsBpNXLC5Tjyl4jlH21x1WqqxA4s9Vie73n9HyoDF9cBIHG0z8EE50HqhUw51Ncb461EfXZZqGwcl35Lr8nUU+rqUXLvWmOI6xyxBdX9AEAAAAAAAABAAAAAAAAAFFGVAAAAAAAAAAAAAAAAA==",
+	},
+	{
+		name:      "asset_possession_change",
+		eventType: TypeAssetPossessionChange,
+		eventData: "Qc9nlLpCALg5xTUxVV8POZjfTLsBpNXLC5Tjyl4jlH21x1WqqxA4s9Vie73n9HyoDF9cBIHG0z8EE50HqhUw51Ncb461EfXZZqGwcl35Lr8nUU+rqUXLvWmOI6xyxBdX9AEAAAAAAAABAAAAAAAAAFFGVAAAAAAAAAAAAAAAAA==",
+	},
+	{
+		name:      "asset_ownership_managing_contract_change",
+		eventType: TypeAssetOwnershipManagingContractChange,
+		eventData: "TBApaX7jWHFdOhSirdgXxLAWUUQN6Ag3H3gWWskNxYFTXG+OtRH12WahsHJd+S6/J1FPq6lFy71pjiOscsQXVwEAAAACAAAA9AEAAAAAAABRRlQAAAAA",
+	},
+	{
+		name:      "asset_possession_managing_contract_change",
+		eventType: TypeAssetPossessionManagingContractChange,
+		eventData: "piijCwNFHpbsWSFeVM1crqM/OOIEminxEPENCsn1jfZMEClpfuNYcV06FKKt2BfEsBZRRA3oCDcfeBZayQ3FgVNcb461EfXZZqGwcl35Lr8nUU+rqUXLvWmOI6xyxBdXAQAAAAIAAAD0AQAAAAAAAFFGVAAAAAA=",
+	},
+	{
+		name:      "contract_information_message",
+		eventType: TypeContractInformationMessage,
+		eventData: "AgAAAAUAAABkAAAAAAAAAP3/////////",
+	},
+	{
+		name:      "custom_message",
+		eventType: TypeCustomMessage,
+		eventData: "Fc1bBwAAAAA=",
+	},
+	{
+		name:      "burning",
+		eventType: TypeBurning,
+		eventData: "Z7jqmuPDHst4ATySX/I33Rp+coRaf4yZKAviUljA0QVAQg8AAAAAAAkAAAA=",
+	},
+	{
+		name:      "burning_without_contract_index",
+		eventType: TypeBurning,
+		eventData: "Z7jqmuPDHst4ATySX/I33Rp+coRaf4yZKAviUljA0QVAQg8AAAAAAA==",
+	},
+	{
+		name:      "dust_burning",
+		eventType: TypeDustBurning,
+		eventData: "AgDNgN84ncLX9VLGCaqFYrqKeMjDiGLhH/iYAhYTz5+/NgMAAAAAAAAA9ibCd1KD/j2WNmB+zFxLLJo5MKeQgSYlTcQfXV1NBYEHAAAAAAAAAA==",
+	},
+	{
+		name:      "spectrum_stats",
+		eventType: TypeSpectrumStats,
+		eventData: "AHC62DpsAADoAwAAAAAAANAHAAAAAAAABgkAAAoAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAADQBwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAALAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==",
+	},
+}
+
+func TestDecode_Golden(t *testing.T) {
+	for _, tt := range goldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := base64.StdEncoding.DecodeString(tt.eventData)
+			require.NoError(t, err)
+
+			decoded, err := Decode(tt.eventType, data)
+			require.NoError(t, err)
+			actual, err := json.MarshalIndent(decoded, "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tt.name+".golden.json")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, append(actual, '\n'), 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		})
+	}
+}
+
+func TestDecode_GivenUnknownType_ThenNil(t *testing.T) {
+	decoded, err := Decode(42, []byte{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestDecode_GivenInvalidLength_ThenError(t *testing.T) {
+	tests := map[uint32]int{
+		TypeQuTransfer:                            71,
+		TypeAssetIssuance:                         62,
+		TypeAssetOwnershipChange:                  126,
+		TypeAssetPossessionChange:                 126,
+		TypeAssetOwnershipManagingContractChange:  86,
+		TypeAssetPossessionManagingContractChange: 118,
+		TypeContractErrorMessage:                  7,
+		TypeCustomMessage:                         7,
+		TypeBurning:                               39,
+		TypeDustBurning:                           1,
+		TypeSpectrumStats:                         219,
+	}
+	for eventType, length := range tests {
+		_, err := Decode(eventType, make([]byte, length))
+		assert.Error(t, err, "event type [%d]", eventType)
+	}
+}
+
+func TestDecode_GivenDustBurningWithMissingEntities_ThenError(t *testing.T) {
+	data := make([]byte, 2+40)
+	data[0] = 2 // two entities, but only one in data
+	_, err := Decode(TypeDustBurning, data)
+	assert.Error(t, err)
+}
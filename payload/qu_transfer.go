@@ -0,0 +1,24 @@
+package payload
+
+import (
+	"encoding/binary"
+)
+
+// QuTransfer is the payload of a qu transfer event.
+type QuTransfer struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Amount      int64  `json:"amount"`
+}
+
+// decodeQuTransfer decodes source public key (32 bytes), destination public key (32 bytes) and amount (int64).
+func decodeQuTransfer(data []byte) (any, error) {
+	if err := checkLength(data, 72); err != nil {
+		return nil, err
+	}
+	return QuTransfer{
+		Source:      readIdentity(data[0:32]),
+		Destination: readIdentity(data[32:64]),
+		Amount:      int64(binary.LittleEndian.Uint64(data[64:72])),
+	}, nil
+}
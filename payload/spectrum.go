@@ -0,0 +1,91 @@
+package payload
+
+import (
+	"encoding/binary"
+)
+
+// Burning is the payload of a burning event.
+type Burning struct {
+	Source                 string  `json:"source"`
+	Amount                 int64   `json:"amount"`
+	ContractIndexBurnedFor *uint32 `json:"contractIndexBurnedFor,omitempty"` // not available in older events
+}
+
+// DustBurning is the payload of a dust burning event.
+type DustBurning struct {
+	Entities []BurnedEntity `json:"entities"`
+}
+
+type BurnedEntity struct {
+	Identity string `json:"identity"`
+	Amount   uint64 `json:"amount"`
+}
+
+// SpectrumStats is the payload of a spectrum stats event. The entity category populations are the number of entities
+// per balance category.
+type SpectrumStats struct {
+	TotalAmount               uint64   `json:"totalAmount"`
+	DustThresholdBurnAll      uint64   `json:"dustThresholdBurnAll"`
+	DustThresholdBurnHalf     uint64   `json:"dustThresholdBurnHalf"`
+	NumberOfEntities          uint32   `json:"numberOfEntities"`
+	EntityCategoryPopulations []uint32 `json:"entityCategoryPopulations"`
+}
+
+const entityCategories = 48
+
+// decodeBurning decodes source public key (32 bytes), amount (int64) and, if available, the index of the contract the
+// amount was burned for (uint32).
+func decodeBurning(data []byte) (any, error) {
+	if err := checkLength(data, 40); err != nil {
+		return nil, err
+	}
+	burning := Burning{
+		Source: readIdentity(data[0:32]),
+		Amount: int64(binary.LittleEndian.Uint64(data[32:40])),
+	}
+	if len(data) >= 44 {
+		contractIndex := binary.LittleEndian.Uint32(data[40:44])
+		burning.ContractIndexBurnedFor = &contractIndex
+	}
+	return burning, nil
+}
+
+// decodeDustBurning decodes the number of burned entities (uint16) followed by public key (32 bytes) and amount
+// (uint64) of each entity.
+func decodeDustBurning(data []byte) (any, error) {
+	if err := checkLength(data, 2); err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint16(data[0:2]))
+	if err := checkLength(data, 2+count*40); err != nil {
+		return nil, err
+	}
+	entities := make([]BurnedEntity, count)
+	for i := range entities {
+		offset := 2 + i*40
+		entities[i] = BurnedEntity{
+			Identity: readIdentity(data[offset : offset+32]),
+			Amount:   binary.LittleEndian.Uint64(data[offset+32 : offset+40]),
+		}
+	}
+	return DustBurning{Entities: entities}, nil
+}
+
+// decodeSpectrumStats decodes total amount, dust threshold burn all and dust threshold burn half (uint64 each), number
+// of entities (uint32) and the populations of the entity categories (48 uint32).
+func decodeSpectrumStats(data []byte) (any, error) {
+	if err := checkLength(data, 28+entityCategories*4); err != nil {
+		return nil, err
+	}
+	populations := make([]uint32, entityCategories)
+	for i := range populations {
+		populations[i] = binary.LittleEndian.Uint32(data[28+i*4:])
+	}
+	return SpectrumStats{
+		TotalAmount:               binary.LittleEndian.Uint64(data[0:8]),
+		DustThresholdBurnAll:      binary.LittleEndian.Uint64(data[8:16]),
+		DustThresholdBurnHalf:     binary.LittleEndian.Uint64(data[16:24]),
+		NumberOfEntities:          binary.LittleEndian.Uint32(data[24:28]),
+		EntityCategoryPopulations: populations,
+	}, nil
+}
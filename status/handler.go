@@ -1,16 +1,38 @@
 package status
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 )
 
 type Handler struct {
+	Status *Status
+}
+
+type response struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	status, reason := Up, ""
+	if h.Status != nil {
+		status, reason = h.Status.Get()
+	}
+
+	body, err := json.Marshal(response{Status: status, Reason: reason})
+	if err != nil {
+		log.Printf("Error marshalling status response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Add("Content-Type", "application/json")
-	_, err := w.Write([]byte("{\"status\":\"UP\"}"))
+	if status != Up {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, err = w.Write(body)
 	if err != nil {
 		log.Printf("Error writing status response: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
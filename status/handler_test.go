@@ -0,0 +1,37 @@
+package status
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	status := NewStatus()
+	handler := &Handler{Status: status}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"status":"UP"}`, recorder.Body.String())
+
+	status.SetDegraded("delivery stalled")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, `{"status":"DEGRADED","reason":"delivery stalled"}`, recorder.Body.String())
+
+	status.SetUp()
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"status":"UP"}`, recorder.Body.String())
+}
+
+func TestHandler_GivenNoStatus_ThenUp(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	(&Handler{}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"status":"UP"}`, recorder.Body.String())
+}
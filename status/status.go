@@ -0,0 +1,39 @@
+package status
+
+import "sync"
+
+const (
+	Up       = "UP"
+	Degraded = "DEGRADED"
+)
+
+// Status holds the current health of the service. It is safe for concurrent use.
+type Status struct {
+	mutex  sync.RWMutex
+	status string
+	reason string
+}
+
+func NewStatus() *Status {
+	return &Status{status: Up}
+}
+
+func (s *Status) SetUp() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status = Up
+	s.reason = ""
+}
+
+func (s *Status) SetDegraded(reason string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status = Degraded
+	s.reason = reason
+}
+
+func (s *Status) Get() (status string, reason string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.status, s.reason
+}
@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"context"
+	_ "embed"
+	"github.com/hamba/avro/v2"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/sr"
+	"strings"
+)
+
+// EventAvroSchema is the avro schema of the event envelope.
+//
+//go:embed event.avsc
+var EventAvroSchema string
+
+const avroContentType = "avro/binary"
+
+// SchemaRegistry is the subset of the schema registry client (see sr.Client) that is needed for registering schemas.
+type SchemaRegistry interface {
+	CheckCompatibility(ctx context.Context, subject string, version int, s sr.Schema) (sr.CheckCompatibilityResult, error)
+	RegisterSchema(ctx context.Context, subject string, s sr.Schema, id, version int) (int, error)
+	LookupSchema(ctx context.Context, subject string, s sr.Schema) (sr.SubjectSchema, error)
+}
+
+type avroEvent struct {
+	Epoch           int64  `avro:"epoch"`
+	Tick            int64  `avro:"tick"`
+	EventId         int64  `avro:"eventId"`
+	EventDigest     int64  `avro:"eventDigest"`
+	TransactionHash string `avro:"transactionHash"`
+	EventType       int64  `avro:"eventType"`
+	EventSize       int64  `avro:"eventSize"`
+	EventData       []byte `avro:"eventData"`
+}
+
+// AvroSerializer serializes the event as avro in the confluent wire format (magic byte, big endian schema id, avro
+// binary data).
+type AvroSerializer struct {
+	schema   avro.Schema
+	schemaId int
+}
+
+// NewAvroSerializer checks the event schema for compatibility with the latest schema of the subject. Then it registers
+// the schema or, if autoRegister is false, looks up the id of the already registered schema.
+func NewAvroSerializer(ctx context.Context, registry SchemaRegistry, subject string, autoRegister bool) (*AvroSerializer, error) {
+	schema, err := avro.Parse(EventAvroSchema)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing avro schema")
+	}
+	registrySchema := sr.Schema{Schema: EventAvroSchema, Type: sr.TypeAvro}
+
+	compatibility, err := registry.CheckCompatibility(ctx, subject, -1, registrySchema)
+	if err != nil && !isSubjectNotFound(err) {
+		return nil, errors.Wrapf(err, "checking schema compatibility of subject [%s]", subject)
+	}
+	if err == nil && !compatibility.Is {
+		return nil, errors.Errorf("schema is not compatible with subject [%s]: %s", subject, strings.Join(compatibility.Messages, "; "))
+	}
+
+	var schemaId int
+	if autoRegister {
+		schemaId, err = registry.RegisterSchema(ctx, subject, registrySchema, -1, -1)
+		if err != nil {
+			return nil, errors.Wrapf(err, "registering schema for subject [%s]", subject)
+		}
+	} else {
+		subjectSchema, err := registry.LookupSchema(ctx, subject, registrySchema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "looking up schema of subject [%s]", subject)
+		}
+		schemaId = subjectSchema.ID
+	}
+
+	return &AvroSerializer{
+		schema:   schema,
+		schemaId: schemaId,
+	}, nil
+}
+
+func (as *AvroSerializer) Serialize(event *Event) ([]byte, error) {
+	eventData, err := decodeEventData(event)
+	if err != nil {
+		return nil, err
+	}
+	data, err := avro.Marshal(as.schema, avroEvent{
+		Epoch:           int64(event.Epoch),
+		Tick:            int64(event.Tick),
+		EventId:         int64(event.EventId),
+		EventDigest:     int64(event.EventDigest),
+		TransactionHash: event.TransactionHash,
+		EventType:       int64(event.EventType),
+		EventSize:       int64(event.EventSize),
+		EventData:       eventData,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling avro event")
+	}
+	payload, _ := new(sr.ConfluentHeader).AppendEncode(make([]byte, 0, 5+len(data)), as.schemaId, nil)
+	return append(payload, data...), nil
+}
+
+func (as *AvroSerializer) ContentType() string {
+	return avroContentType
+}
+
+func (as *AvroSerializer) SchemaVersion() int {
+	return SchemaVersion1
+}
+
+// isSubjectNotFound returns true, if there is no schema registered for the subject yet.
+func isSubjectNotFound(err error) bool {
+	var responseErr *sr.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.ErrorCode == sr.ErrSubjectNotFound.Code || responseErr.ErrorCode == sr.ErrVersionNotFound.Code
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package sync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/sr"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// FakeRegistry is a minimal confluent compatible schema registry.
+type FakeRegistry struct {
+	schemas      map[string]int // subject -> schema id
+	incompatible bool
+	registered   int
+}
+
+func (fr *FakeRegistry) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /compatibility/subjects/{subject}/versions/latest", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := fr.schemas[r.PathValue("subject")]; !ok {
+			writeRegistryResponse(w, http.StatusNotFound, map[string]any{"error_code": 40401, "message": "Subject not found."})
+			return
+		}
+		if fr.incompatible {
+			writeRegistryResponse(w, http.StatusOK, map[string]any{"is_compatible": false, "messages": []string{"field removed"}})
+			return
+		}
+		writeRegistryResponse(w, http.StatusOK, map[string]any{"is_compatible": true})
+	})
+	mux.HandleFunc("POST /subjects/{subject}/versions", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+		if _, ok := fr.schemas[subject]; !ok {
+			fr.schemas[subject] = 100 + len(fr.schemas)
+		}
+		fr.registered++
+		writeRegistryResponse(w, http.StatusOK, map[string]any{"id": fr.schemas[subject]})
+	})
+	mux.HandleFunc("POST /subjects/{subject}", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+		id, ok := fr.schemas[subject]
+		if !ok {
+			writeRegistryResponse(w, http.StatusNotFound, map[string]any{"error_code": 40401, "message": "Subject not found."})
+			return
+		}
+		writeRegistryResponse(w, http.StatusOK, map[string]any{"subject": subject, "id": id, "version": 1})
+	})
+	return mux
+}
+
+func writeRegistryResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func newTestRegistry(t *testing.T, fakeRegistry *FakeRegistry) SchemaRegistry {
+	server := httptest.NewServer(fakeRegistry.handler())
+	t.Cleanup(server.Close)
+	client, err := sr.NewClient(sr.URLs(server.URL))
+	require.NoError(t, err)
+	return client
+}
+
+func TestAvroSerializer_Serialize(t *testing.T) {
+	fakeRegistry := &FakeRegistry{schemas: map[string]int{}}
+	serializer, err := NewAvroSerializer(context.Background(), newTestRegistry(t, fakeRegistry), "topic-value", true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fakeRegistry.registered)
+
+	event := testTransferEvent()
+	payload, err := serializer.Serialize(event)
+	require.NoError(t, err)
+
+	// confluent wire format: magic byte, schema id
+	require.Greater(t, len(payload), 5)
+	assert.Equal(t, byte(0), payload[0])
+	assert.Equal(t, uint32(100), binary.BigEndian.Uint32(payload[1:5]))
+
+	var result avroEvent
+	require.NoError(t, avro.Unmarshal(avro.MustParse(EventAvroSchema), payload[5:], &result))
+	data, err := base64.StdEncoding.DecodeString(event.EventData)
+	require.NoError(t, err)
+	assert.Equal(t, avroEvent{
+		Epoch:           153,
+		Tick:            21679416,
+		EventId:         13857,
+		EventDigest:     1715952909454684526,
+		TransactionHash: "wjydyydyoltqlfdvnldtqqargoiamutsfqjnojyjhemhbrckrvxeyjodnfil",
+		EventType:       0,
+		EventSize:       72,
+		EventData:       data,
+	}, result)
+	assert.Equal(t, "avro/binary", serializer.ContentType())
+}
+
+func TestNewAvroSerializer_GivenRegisteredSchema_ThenLookupId(t *testing.T) {
+	fakeRegistry := &FakeRegistry{schemas: map[string]int{"topic-value": 42}}
+	serializer, err := NewAvroSerializer(context.Background(), newTestRegistry(t, fakeRegistry), "topic-value", false)
+	require.NoError(t, err)
+	assert.Equal(t, 42, serializer.schemaId)
+	assert.Equal(t, 0, fakeRegistry.registered)
+}
+
+func TestNewAvroSerializer_GivenUnregisteredSchemaAndNoAutoRegister_ThenError(t *testing.T) {
+	fakeRegistry := &FakeRegistry{schemas: map[string]int{}}
+	_, err := NewAvroSerializer(context.Background(), newTestRegistry(t, fakeRegistry), "topic-value", false)
+	assert.Error(t, err)
+}
+
+func TestNewAvroSerializer_GivenIncompatibleSchema_ThenError(t *testing.T) {
+	fakeRegistry := &FakeRegistry{schemas: map[string]int{"topic-value": 42}, incompatible: true}
+	_, err := NewAvroSerializer(context.Background(), newTestRegistry(t, fakeRegistry), "topic-value", true)
+	assert.ErrorContains(t, err, "field removed")
+	assert.Equal(t, 0, fakeRegistry.registered)
+}
+
+func TestNewSerializers_GivenAvroTopic(t *testing.T) {
+	fakeRegistry := &FakeRegistry{schemas: map[string]int{}}
+	serializers, err := NewSerializers(context.Background(), map[string]string{"avro-topic": FormatAvro}, SchemaVersion1, newTestRegistry(t, fakeRegistry), true)
+	require.NoError(t, err)
+	assert.IsType(t, &AvroSerializer{}, serializers.get("avro-topic"))
+	assert.Equal(t, map[string]int{"avro-topic-value": 100}, fakeRegistry.schemas)
+}
+
+func TestNewSerializers_GivenAvroTopicWithoutRegistry_ThenError(t *testing.T) {
+	_, err := NewSerializers(context.Background(), map[string]string{"avro-topic": FormatAvro}, SchemaVersion1, nil, true)
+	assert.Error(t, err)
+}
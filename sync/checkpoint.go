@@ -0,0 +1,135 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"log"
+	"slices"
+)
+
+// tickMarkerLookback is the number of offsets per partition that are read to find the last committed tick marker.
+// Transaction control records occupy offsets, too.
+const tickMarkerLookback = 100
+
+// TickMarkerReader reads the last committed tick marker.
+type TickMarkerReader interface {
+	LastCommittedTickMarker(ctx context.Context) (*TickMarker, error)
+}
+
+// KafkaTickMarkerReader reads the tick marker topic with read_committed isolation, so that markers of aborted or open
+// transactions are ignored.
+type KafkaTickMarkerReader struct {
+	admin *kadm.Client
+	topic string
+	opts  []kgo.Opt
+}
+
+// NewKafkaTickMarkerReader creates a reader for the tick marker topic. The options are used for the consumer client
+// and need to contain the connection settings (seed brokers, tls, sasl).
+func NewKafkaTickMarkerReader(admin *kadm.Client, topic string, opts ...kgo.Opt) *KafkaTickMarkerReader {
+	return &KafkaTickMarkerReader{
+		admin: admin,
+		topic: topic,
+		opts:  opts,
+	}
+}
+
+// LastCommittedTickMarker returns the latest (by epoch and tick) committed tick marker of all partitions. Returns nil,
+// if there is no marker.
+func (r *KafkaTickMarkerReader) LastCommittedTickMarker(ctx context.Context) (*TickMarker, error) {
+	offsets, err := r.admin.ListCommittedOffsets(ctx, r.topic)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing committed offsets of topic [%s]", r.topic)
+	}
+	if err = offsets.Error(); err != nil {
+		return nil, errors.Wrapf(err, "listing committed offsets of topic [%s]", r.topic)
+	}
+
+	partitions := make(map[int32]kgo.Offset)
+	endOffsets := make(map[int32]int64) // last stable offset per partition
+	offsets.Each(func(offset kadm.ListedOffset) {
+		if offset.Offset > 0 {
+			partitions[offset.Partition] = kgo.NewOffset().At(max(0, offset.Offset-tickMarkerLookback))
+			endOffsets[offset.Partition] = offset.Offset
+		}
+	})
+	if len(partitions) == 0 {
+		return nil, nil
+	}
+
+	consumer, err := kgo.NewClient(append(slices.Clone(r.opts),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{r.topic: partitions}),
+		kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+		kgo.KeepControlRecords(), // needed to detect, that the end of a partition is reached
+	)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating tick marker consumer")
+	}
+	defer consumer.Close()
+
+	var last *TickMarker
+	for len(endOffsets) > 0 {
+		fetches := consumer.PollFetches(ctx)
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err(), "reading tick markers")
+		}
+		if fetchErrors := fetches.Errors(); len(fetchErrors) > 0 {
+			return nil, errors.Wrapf(fetchErrors[0].Err, "reading tick markers of partition [%d]", fetchErrors[0].Partition)
+		}
+		var decodeErr error
+		fetches.EachRecord(func(record *kgo.Record) {
+			if record.Offset+1 >= endOffsets[record.Partition] {
+				delete(endOffsets, record.Partition)
+			}
+			if record.Attrs.IsControl() || decodeErr != nil {
+				return
+			}
+			var marker TickMarker
+			err := json.Unmarshal(record.Value, &marker)
+			if err != nil {
+				decodeErr = errors.Wrapf(err, "decoding tick marker at offset [%d]", record.Offset)
+				return
+			}
+			if last == nil || marker.Epoch > last.Epoch || (marker.Epoch == last.Epoch && marker.Tick > last.Tick) {
+				last = &marker
+			}
+		})
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+	}
+	return last, nil
+}
+
+// RestoreCheckpoint sets the last processed tick to the last committed tick marker, if the marker is later. With
+// transactional publishing the marker is committed together with the events of the tick, while the store is updated
+// after the commit. If the publisher stops in between, the marker is the checkpoint and the tick is not published
+// again.
+func RestoreCheckpoint(ctx context.Context, reader TickMarkerReader, store DataStore) error {
+	marker, err := reader.LastCommittedTickMarker(ctx)
+	if err != nil {
+		return errors.Wrap(err, "reading last committed tick marker")
+	}
+	if marker == nil {
+		log.Printf("No committed tick marker found.")
+		return nil
+	}
+
+	lastProcessedTick, err := store.GetLastProcessedTick(marker.Epoch)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return errors.Wrap(err, "getting last processed tick")
+	}
+	if marker.Tick <= lastProcessedTick {
+		return nil
+	}
+
+	log.Printf("Restoring last processed tick [%d] in epoch [%d] from committed tick marker (stored: [%d]).", marker.Tick, marker.Epoch, lastProcessedTick)
+	err = store.SetLastProcessedTick(marker.Epoch, marker.Tick)
+	if err != nil {
+		return errors.Wrapf(err, "setting last processed tick [%d]", marker.Tick)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// FakeTickMarkerReader returns the last tick marker of the committed records.
+type FakeTickMarkerReader struct {
+	kcl   *FakeTransactionalKafkaClient
+	topic string
+	err   error
+}
+
+func (r *FakeTickMarkerReader) LastCommittedTickMarker(_ context.Context) (*TickMarker, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var last *TickMarker
+	for _, record := range r.kcl.committedRecords {
+		if record.Topic != r.topic {
+			continue
+		}
+		var marker TickMarker
+		err := json.Unmarshal(record.Value, &marker)
+		if err != nil {
+			return nil, err
+		}
+		last = &marker
+	}
+	return last, nil
+}
+
+func TestRestoreCheckpoint_GivenStopAfterCommit_ThenResumeAfterCommittedTick(t *testing.T) {
+	cleanStore(t, 123)
+	require.NoError(t, store.SetLastProcessedTick(123, 12344))
+	kafkaClient := &FakeTransactionalKafkaClient{}
+	producer := NewTransactionalEventProducer(kafkaClient, NewEventProducer(kafkaClient, WithTickMarkers("markers")))
+
+	// tick is committed, but the publisher stops before storing it
+	_, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	require.NoError(t, err)
+
+	err = RestoreCheckpoint(context.Background(), &FakeTickMarkerReader{kcl: kafkaClient, topic: "markers"}, store)
+	require.NoError(t, err)
+	tick, err := store.GetLastProcessedTick(123)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(12345), tick)
+}
+
+func TestRestoreCheckpoint_GivenAbortedTick_ThenKeepStoredTick(t *testing.T) {
+	cleanStore(t, 123)
+	require.NoError(t, store.SetLastProcessedTick(123, 12345))
+	kafkaClient := &FakeTransactionalKafkaClient{}
+	producer := NewTransactionalEventProducer(kafkaClient, NewEventProducer(kafkaClient, WithTickMarkers("markers")))
+	_, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	require.NoError(t, err)
+
+	kafkaClient.produceErr = errors.New("test error")
+	_, err = producer.ProcessTickEvents(context.Background(), 123, &eventspb.TickEvents{Tick: 12346, TxEvents: testTickEvents().TxEvents})
+	require.Error(t, err)
+
+	err = RestoreCheckpoint(context.Background(), &FakeTickMarkerReader{kcl: kafkaClient, topic: "markers"}, store)
+	require.NoError(t, err)
+	tick, err := store.GetLastProcessedTick(123)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(12345), tick)
+}
+
+func TestRestoreCheckpoint_GivenStoredTickAfterMarker_ThenKeepStoredTick(t *testing.T) {
+	cleanStore(t, 123)
+	require.NoError(t, store.SetLastProcessedTick(123, 12350))
+	kafkaClient := &FakeTransactionalKafkaClient{}
+	producer := NewTransactionalEventProducer(kafkaClient, NewEventProducer(kafkaClient, WithTickMarkers("markers")))
+	_, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	require.NoError(t, err)
+
+	err = RestoreCheckpoint(context.Background(), &FakeTickMarkerReader{kcl: kafkaClient, topic: "markers"}, store)
+	require.NoError(t, err)
+	tick, err := store.GetLastProcessedTick(123)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(12350), tick)
+}
+
+func TestRestoreCheckpoint_GivenNoMarker_ThenKeepStore(t *testing.T) {
+	cleanStore(t, 123)
+
+	err := RestoreCheckpoint(context.Background(), &FakeTickMarkerReader{kcl: &FakeTransactionalKafkaClient{}, topic: "markers"}, store)
+	require.NoError(t, err)
+	_, err = store.GetLastProcessedTick(123)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRestoreCheckpoint_GivenReadError_ThenError(t *testing.T) {
+	err := RestoreCheckpoint(context.Background(), &FakeTickMarkerReader{err: errors.New("test error")}, store)
+	assert.Error(t, err)
+}
+
+func cleanStore(t *testing.T, epoch uint32) {
+	require.NoError(t, store.deleteLastProcessedTicks(epoch, epoch+1))
+}
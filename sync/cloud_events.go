@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/qubic/go-events-publisher/payload"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	CloudEventsNone       = "none"
+	CloudEventsBinary     = "binary"
+	CloudEventsStructured = "structured"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsContentType = "application/cloudevents+json"
+	cloudEventsTypePrefix  = "org.qubic.event."
+)
+
+var cloudEventTypes = map[uint32]string{
+	payload.TypeQuTransfer:                            "qu-transfer",
+	payload.TypeAssetIssuance:                         "asset-issuance",
+	payload.TypeAssetOwnershipChange:                  "asset-ownership-change",
+	payload.TypeAssetPossessionChange:                 "asset-possession-change",
+	payload.TypeContractErrorMessage:                  "contract-error-message",
+	payload.TypeContractWarningMessage:                "contract-warning-message",
+	payload.TypeContractInformationMessage:            "contract-information-message",
+	payload.TypeContractDebugMessage:                  "contract-debug-message",
+	payload.TypeBurning:                               "burning",
+	payload.TypeDustBurning:                           "dust-burning",
+	payload.TypeSpectrumStats:                         "spectrum-stats",
+	payload.TypeAssetOwnershipManagingContractChange:  "asset-ownership-managing-contract-change",
+	payload.TypeAssetPossessionManagingContractChange: "asset-possession-managing-contract-change",
+	payload.TypeCustomMessage:                         "custom-message",
+}
+
+// CloudEvents wraps the event records as cloud events (version 1.0, kafka protocol binding). In binary mode the
+// attributes are sent as `ce_*` headers and the value is the serialized event. In structured mode the value is a json
+// envelope containing the attributes and the serialized event as data.
+type CloudEvents struct {
+	mode   string
+	source string
+}
+
+// NewCloudEvents creates the cloud events wrapper for the given mode. The source needs to be a URI reference. Returns
+// nil for mode none.
+func NewCloudEvents(mode, source string) (*CloudEvents, error) {
+	switch mode {
+	case CloudEventsNone:
+		return nil, nil
+	case CloudEventsBinary, CloudEventsStructured:
+		if _, err := url.Parse(source); err != nil || source == "" {
+			return nil, errors.Errorf("invalid cloud events source [%s]", source)
+		}
+		return &CloudEvents{mode: mode, source: source}, nil
+	default:
+		return nil, errors.Errorf("unknown cloud events mode [%s]", mode)
+	}
+}
+
+// CloudEventsSource returns the default source for the event service endpoint. Endpoints without scheme (host:port)
+// get the `grpc` scheme, as `host:port` would be parsed with the host as scheme.
+func CloudEventsSource(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	return "grpc://" + endpoint
+}
+
+// CheckFormats returns an error, if a topic format cannot be wrapped. Avro topics are registry managed and need the
+// confluent wire format instead of a json envelope.
+func (ce *CloudEvents) CheckFormats(topicFormats map[string]string) error {
+	if ce == nil || ce.mode != CloudEventsStructured {
+		return nil
+	}
+	for topic, format := range topicFormats {
+		if format == FormatAvro {
+			return errors.Errorf("topic [%s] with format [%s] does not support structured cloud events", topic, format)
+		}
+	}
+	return nil
+}
+
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// wrap converts the record with the serialized event into a cloud event record. Returns the content type of the
+// resulting record value.
+func (ce *CloudEvents) wrap(record *kgo.Record, event *Event, dataContentType string, publishTime time.Time) (string, error) {
+	attributes := cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		Id:              cloudEventId(event),
+		Source:          ce.source,
+		Type:            cloudEventType(event.EventType),
+		Time:            publishTime.UTC(),
+		DataContentType: dataContentType,
+	}
+
+	if ce.mode == CloudEventsBinary {
+		record.Headers = append(record.Headers,
+			kgo.RecordHeader{Key: "ce_specversion", Value: []byte(attributes.SpecVersion)},
+			kgo.RecordHeader{Key: "ce_id", Value: []byte(attributes.Id)},
+			kgo.RecordHeader{Key: "ce_source", Value: []byte(attributes.Source)},
+			kgo.RecordHeader{Key: "ce_type", Value: []byte(attributes.Type)},
+			kgo.RecordHeader{Key: "ce_time", Value: []byte(attributes.Time.Format(time.RFC3339Nano))},
+			kgo.RecordHeader{Key: "content-type", Value: []byte(dataContentType)},
+		)
+		return dataContentType, nil
+	}
+
+	// structured mode. Binary data formats need to be base64 encoded.
+	if dataContentType == jsonContentType {
+		attributes.Data = record.Value
+	} else {
+		attributes.DataBase64 = record.Value
+	}
+	value, err := json.Marshal(attributes)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling cloud event")
+	}
+	record.Value = value
+	record.Headers = append(record.Headers, kgo.RecordHeader{Key: "content-type", Value: []byte(cloudEventsContentType)})
+	return cloudEventsContentType, nil
+}
+
+// cloudEventId returns the unique id of the event (epoch/tick/eventId).
+func cloudEventId(event *Event) string {
+	return fmt.Sprintf("%d/%d/%d", event.Epoch, event.Tick, event.EventId)
+}
+
+func cloudEventType(eventType uint32) string {
+	name, ok := cloudEventTypes[eventType]
+	if !ok {
+		name = fmt.Sprintf("type-%d", eventType)
+	}
+	return cloudEventsTypePrefix + name
+}
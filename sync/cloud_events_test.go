@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"testing"
+	"time"
+)
+
+var testPublishTime = time.Date(2025, 4, 1, 12, 30, 0, 0, time.UTC)
+
+func TestCloudEvents_wrap_GivenBinaryMode_ThenAddHeaders(t *testing.T) {
+	cloudEvents, err := NewCloudEvents(CloudEventsBinary, "grpc://localhost:8003")
+	require.NoError(t, err)
+	record := &kgo.Record{Value: []byte(`{"tick":21679416}`)}
+
+	contentType, err := cloudEvents.wrap(record, testTransferEvent(), jsonContentType, testPublishTime)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, []byte(`{"tick":21679416}`), record.Value)
+	assert.Equal(t, "1.0", headerValue(record, "ce_specversion"))
+	assert.Equal(t, "153/21679416/13857", headerValue(record, "ce_id"))
+	assert.Equal(t, "grpc://localhost:8003", headerValue(record, "ce_source"))
+	assert.Equal(t, "org.qubic.event.qu-transfer", headerValue(record, "ce_type"))
+	assert.Equal(t, "2025-04-01T12:30:00Z", headerValue(record, "ce_time"))
+	assert.Equal(t, "application/json", headerValue(record, "content-type"))
+}
+
+func TestCloudEvents_wrap_GivenStructuredMode_ThenCreateEnvelope(t *testing.T) {
+	cloudEvents, err := NewCloudEvents(CloudEventsStructured, "grpc://localhost:8003")
+	require.NoError(t, err)
+	record := &kgo.Record{Value: []byte(`{"tick":21679416}`)}
+
+	contentType, err := cloudEvents.wrap(record, testTransferEvent(), jsonContentType, testPublishTime)
+	require.NoError(t, err)
+	assert.Equal(t, "application/cloudevents+json", contentType)
+	assert.Equal(t, "application/cloudevents+json", headerValue(record, "content-type"))
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "153/21679416/13857",
+		"source": "grpc://localhost:8003",
+		"type": "org.qubic.event.qu-transfer",
+		"time": "2025-04-01T12:30:00Z",
+		"datacontenttype": "application/json",
+		"data": {"tick": 21679416}
+	}`, string(record.Value))
+}
+
+func TestCloudEvents_wrap_GivenStructuredModeAndBinaryData_ThenBase64Data(t *testing.T) {
+	cloudEvents, err := NewCloudEvents(CloudEventsStructured, "grpc://localhost:8003")
+	require.NoError(t, err)
+	record := &kgo.Record{Value: []byte{0x01, 0x02, 0x03}}
+
+	_, err = cloudEvents.wrap(record, testTransferEvent(), protobufContentType, testPublishTime)
+	require.NoError(t, err)
+
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal(record.Value, &envelope))
+	assert.Equal(t, "application/x-protobuf", envelope["datacontenttype"])
+	assert.Equal(t, "AQID", envelope["data_base64"])
+	assert.NotContains(t, envelope, "data")
+}
+
+func TestNewCloudEvents(t *testing.T) {
+	cloudEvents, err := NewCloudEvents(CloudEventsNone, "grpc://localhost:8003")
+	assert.NoError(t, err)
+	assert.Nil(t, cloudEvents)
+
+	_, err = NewCloudEvents("foo", "grpc://localhost:8003")
+	assert.Error(t, err)
+}
+
+func TestCloudEventType(t *testing.T) {
+	assert.Equal(t, "org.qubic.event.asset-issuance", cloudEventType(1))
+	assert.Equal(t, "org.qubic.event.custom-message", cloudEventType(255))
+	assert.Equal(t, "org.qubic.event.type-42", cloudEventType(42))
+}
+
+func TestEventProducer_GivenCloudEvents_ThenContentTypeHeaderOfEnvelope(t *testing.T) {
+	headers, err := NewRecordHeaders([]string{HeaderContentType}, "", "")
+	require.NoError(t, err)
+	cloudEvents, err := NewCloudEvents(CloudEventsStructured, "grpc://localhost:8003")
+	require.NoError(t, err)
+	kafkaClient := &FakeKafkaClient{}
+	producer := NewEventProducer(kafkaClient, WithRecordHeaders(headers), WithCloudEvents(cloudEvents))
+
+	record, err := producer.createEventRecord(testTransferEvent())
+	require.NoError(t, err)
+	assert.Equal(t, []kgo.RecordHeader{
+		{Key: "contentType", Value: []byte("application/cloudevents+json")},
+		{Key: "content-type", Value: []byte("application/cloudevents+json")},
+	}, record.Headers)
+}
+
+func TestNewCloudEvents_GivenInvalidSource_ThenError(t *testing.T) {
+	_, err := NewCloudEvents(CloudEventsBinary, "")
+	assert.Error(t, err)
+
+	_, err = NewCloudEvents(CloudEventsBinary, "grpc://local host")
+	assert.Error(t, err)
+}
+
+func TestCloudEventsSource(t *testing.T) {
+	assert.Equal(t, "grpc://localhost:8003", CloudEventsSource("localhost:8003"))
+	assert.Equal(t, "https://events.qubic.org", CloudEventsSource("https://events.qubic.org"))
+}
+
+func TestCloudEvents_CheckFormats_GivenStructuredModeAndAvroTopic_ThenError(t *testing.T) {
+	topicFormats := map[string]string{"json-topic": FormatJson, "avro-topic": FormatAvro}
+
+	structured, err := NewCloudEvents(CloudEventsStructured, "grpc://localhost:8003")
+	require.NoError(t, err)
+	assert.ErrorContains(t, structured.CheckFormats(topicFormats), "avro-topic")
+
+	binary, err := NewCloudEvents(CloudEventsBinary, "grpc://localhost:8003")
+	require.NoError(t, err)
+	assert.NoError(t, binary.CheckFormats(topicFormats))
+
+	var none *CloudEvents
+	assert.NoError(t, none.CheckFormats(topicFormats))
+}
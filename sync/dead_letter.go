@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"log"
+	"strconv"
+)
+
+const (
+	PolicyHalt       = "halt"
+	PolicyDeadLetter = "dead-letter"
+)
+
+const (
+	HeaderDeadLetterReason = "deadLetterReason"
+	HeaderDeadLetterTopic  = "deadLetterTopic"
+	// HeaderDeadLetterValueDropped is set, if the original value was too large for kafka and is not part of the record.
+	HeaderDeadLetterValueDropped = "deadLetterValueDropped"
+)
+
+// DeadLetterQueue takes events that cannot be serialized or that are rejected by kafka. The main stream continues
+// instead of retrying the failing tick forever.
+type DeadLetterQueue struct {
+	topic   string
+	metrics *Metrics
+}
+
+func NewDeadLetterQueue(topic string, metrics *Metrics) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		topic:   topic,
+		metrics: metrics,
+	}
+}
+
+// createRecord creates the dead letter record for the event. The value is the original payload, if available, or the
+// json serialized event otherwise. If the original record was rejected because of its size, the value is dropped, as
+// the dead letter topic would reject it, too. Error reason and event coordinates are added as headers.
+func (dlq *DeadLetterQueue) createRecord(event *Event, original *kgo.Record, cause error) *kgo.Record {
+	record := &kgo.Record{Topic: dlq.topic}
+	var originalTopic string
+	valueDropped := false
+	if original != nil {
+		record.Key = original.Key
+		originalTopic = original.Topic
+		if isTooLarge(cause) {
+			valueDropped = true
+		} else {
+			record.Value = original.Value
+		}
+	} else {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Error marshalling dead letter event: %v", err)
+		}
+		record.Value = payload
+	}
+
+	record.Headers = []kgo.RecordHeader{
+		{Key: HeaderEpoch, Value: []byte(strconv.FormatUint(uint64(event.Epoch), 10))},
+		{Key: HeaderTick, Value: []byte(strconv.FormatUint(uint64(event.Tick), 10))},
+		{Key: HeaderEventId, Value: []byte(strconv.FormatUint(event.EventId, 10))},
+		{Key: HeaderEventType, Value: []byte(strconv.FormatUint(uint64(event.EventType), 10))},
+		{Key: HeaderTransactionHash, Value: []byte(event.TransactionHash)},
+		{Key: HeaderDeadLetterTopic, Value: []byte(originalTopic)},
+		{Key: HeaderDeadLetterReason, Value: []byte(cause.Error())},
+	}
+	if valueDropped {
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: HeaderDeadLetterValueDropped, Value: []byte("true")})
+	}
+	return record
+}
+
+func (dlq *DeadLetterQueue) addDeadLetters(count int) {
+	if dlq.metrics != nil {
+		dlq.metrics.AddDeadLetters(count)
+	}
+}
+
+// isUnpublishable returns true, if kafka will never accept the record. Other errors (network, timeouts,...) are
+// expected to be transient.
+func isUnpublishable(err error) bool {
+	return isTooLarge(err) ||
+		errors.Is(err, kerr.InvalidRecord) ||
+		errors.Is(err, kerr.CorruptMessage) ||
+		errors.Is(err, kerr.InvalidTimestamp)
+}
+
+// isTooLarge returns true, if kafka rejected the record because of its size.
+func isTooLarge(err error) bool {
+	return errors.Is(err, kerr.MessageTooLarge) || errors.Is(err, kerr.RecordListTooLarge)
+}
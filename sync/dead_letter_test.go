@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"strings"
+	"testing"
+)
+
+const testDeadLetterTopic = "dead-letter-topic"
+
+// RejectingKafkaClient rejects all records of the given event ids. Dead letters are accepted.
+type RejectingKafkaClient struct {
+	FakeKafkaClient
+	rejectedEventIds map[string]bool
+	rejectErr        error
+}
+
+func (rkc *RejectingKafkaClient) Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	rkc.FakeKafkaClient.Produce(ctx, r, func(r *kgo.Record, err error) {
+		if r.Topic != testDeadLetterTopic && rkc.rejectedEventIds[string(r.Key)] {
+			err = rkc.rejectErr
+		}
+		promise(r, err)
+	})
+}
+
+func deadLetterTestTickEvents() *eventspb.TickEvents {
+	return &eventspb.TickEvents{
+		Tick: 12345,
+		TxEvents: []*eventspb.TransactionEvents{
+			{
+				TxId: "tx-id-1",
+				Events: []*eventspb.Event{
+					{Header: &eventspb.Event_Header{Epoch: 100, EventId: 1}},
+					{Header: &eventspb.Event_Header{Epoch: 100, EventId: 2}},
+					{Header: &eventspb.Event_Header{Epoch: 100, EventId: 3}},
+				},
+			},
+		},
+	}
+}
+
+func eventIdKey(epoch uint32, eventId uint64) string {
+	key, _ := EventIdKeyStrategy{}.Key(&Event{Epoch: epoch, EventId: eventId})
+	return string(key)
+}
+
+func TestEventProducer_ProcessTickEvents_GivenRejectedEvent_ThenSendToDeadLetterTopic(t *testing.T) {
+	kafkaClient := &RejectingKafkaClient{
+		rejectedEventIds: map[string]bool{eventIdKey(100, 2): true},
+		rejectErr:        kerr.InvalidRecord,
+	}
+	pub := NewEventProducer(kafkaClient,
+		WithKeyStrategy(EventIdKeyStrategy{}),
+		WithDeadLetterQueue(NewDeadLetterQueue(testDeadLetterTopic, metrics)),
+	)
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, deadLetterTestTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, kafkaClient.records, 4)
+
+	deadLetter := kafkaClient.records[3]
+	assert.Equal(t, testDeadLetterTopic, deadLetter.Topic)
+	assert.Equal(t, kafkaClient.records[1].Value, deadLetter.Value)
+	assert.Contains(t, headerValue(deadLetter, HeaderDeadLetterReason), "INVALID_RECORD")
+	assert.Equal(t, "2", headerValue(deadLetter, HeaderEventId))
+	assert.Equal(t, "12345", headerValue(deadLetter, HeaderTick))
+	assert.Empty(t, headerValue(deadLetter, HeaderDeadLetterValueDropped))
+}
+
+// SizeLimitedKafkaClient rejects all records with a value larger than the limit, on all topics.
+type SizeLimitedKafkaClient struct {
+	FakeKafkaClient
+	maxValueBytes int
+}
+
+func (skc *SizeLimitedKafkaClient) Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	skc.FakeKafkaClient.Produce(ctx, r, func(r *kgo.Record, err error) {
+		if len(r.Value) > skc.maxValueBytes {
+			err = kerr.MessageTooLarge
+		}
+		promise(r, err)
+	})
+}
+
+func TestEventProducer_ProcessTickEvents_GivenTooLargeEvent_ThenSendDeadLetterWithoutValue(t *testing.T) {
+	kafkaClient := &SizeLimitedKafkaClient{maxValueBytes: 200}
+	pub := NewEventProducer(kafkaClient,
+		WithKeyStrategy(EventIdKeyStrategy{}),
+		WithDeadLetterQueue(NewDeadLetterQueue(testDeadLetterTopic, metrics)),
+	)
+
+	tickEvents := deadLetterTestTickEvents()
+	tickEvents.TxEvents[0].Events[1].EventData = strings.Repeat("A", 400)
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, tickEvents)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, kafkaClient.records, 4)
+
+	deadLetter := kafkaClient.records[3]
+	assert.Equal(t, testDeadLetterTopic, deadLetter.Topic)
+	assert.Empty(t, deadLetter.Value)
+	assert.Equal(t, []byte(eventIdKey(100, 2)), deadLetter.Key)
+	assert.Contains(t, headerValue(deadLetter, HeaderDeadLetterReason), "MESSAGE_TOO_LARGE")
+	assert.Equal(t, "true", headerValue(deadLetter, HeaderDeadLetterValueDropped))
+	assert.Equal(t, "2", headerValue(deadLetter, HeaderEventId))
+	assert.Equal(t, "12345", headerValue(deadLetter, HeaderTick))
+}
+
+func TestEventProducer_ProcessTickEvents_GivenTransientError_ThenReturnError(t *testing.T) {
+	kafkaClient := &RejectingKafkaClient{
+		rejectedEventIds: map[string]bool{eventIdKey(100, 2): true},
+		rejectErr:        errors.New("test error"),
+	}
+	pub := NewEventProducer(kafkaClient,
+		WithKeyStrategy(EventIdKeyStrategy{}),
+		WithDeadLetterQueue(NewDeadLetterQueue(testDeadLetterTopic, metrics)),
+	)
+
+	_, err := pub.ProcessTickEvents(context.Background(), 123, deadLetterTestTickEvents())
+	require.Error(t, err)
+	for _, record := range kafkaClient.records {
+		assert.NotEqual(t, testDeadLetterTopic, record.Topic)
+	}
+}
+
+func TestEventProducer_ProcessTickEvents_GivenUnserializableEvent_ThenSendToDeadLetterTopic(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	pub := NewEventProducer(kafkaClient,
+		WithTopicRouter(NewTopicRouter("topic", nil)),
+		WithSerializers(protobufTestSerializers(t)),
+		WithDeadLetterQueue(NewDeadLetterQueue(testDeadLetterTopic, metrics)),
+	)
+
+	tickEvents := &eventspb.TickEvents{
+		Tick: 12345,
+		TxEvents: []*eventspb.TransactionEvents{
+			{
+				TxId: "tx-id-1",
+				Events: []*eventspb.Event{
+					{Header: &eventspb.Event_Header{EventId: 1}, EventType: 0, EventData: "invalid"},
+					{Header: &eventspb.Event_Header{EventId: 2}, EventType: 1},
+				},
+			},
+		},
+	}
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, tickEvents)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, kafkaClient.records, 2)
+
+	deadLetter := kafkaClient.records[1]
+	assert.Equal(t, testDeadLetterTopic, deadLetter.Topic)
+	assert.JSONEq(t, `{"epoch":0,"tick":12345,"eventId":1,"eventDigest":0,"transactionHash":"tx-id-1","eventType":0,"eventSize":0,"eventData":"invalid"}`, string(deadLetter.Value))
+	assert.Equal(t, "1", headerValue(deadLetter, HeaderEventId))
+	assert.NotEmpty(t, headerValue(deadLetter, HeaderDeadLetterReason))
+}
+
+func TestEventProducer_ProcessTickEvents_GivenUnserializableEventAndNoDeadLetterQueue_ThenReturnError(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	pub := NewEventProducer(kafkaClient,
+		WithTopicRouter(NewTopicRouter("topic", nil)),
+		WithSerializers(protobufTestSerializers(t)),
+	)
+
+	tickEvents := &eventspb.TickEvents{
+		Tick: 12345,
+		TxEvents: []*eventspb.TransactionEvents{
+			{
+				TxId:   "tx-id-1",
+				Events: []*eventspb.Event{{Header: &eventspb.Event_Header{EventId: 1}, EventType: 0, EventData: "invalid"}},
+			},
+		},
+	}
+
+	_, err := pub.ProcessTickEvents(context.Background(), 123, tickEvents)
+	require.Error(t, err)
+	assert.Empty(t, kafkaClient.records)
+}
+
+// protobufTestSerializers serializes the topic `topic` as protobuf, that fails for invalid event data.
+func protobufTestSerializers(t *testing.T) *Serializers {
+	serializers, err := NewSerializers(context.Background(), map[string]string{"topic": FormatProtobuf}, SchemaVersion1, nil, false)
+	require.NoError(t, err)
+	return serializers
+}
+
+func headerValue(record *kgo.Record, key string) string {
+	for _, header := range record.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
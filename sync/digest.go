@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"encoding/binary"
+	"github.com/cloudflare/circl/xof/k12"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	PolicyNone = "none"
+	PolicyFlag = "flag"
+)
+
+const HeaderDigestMismatch = "digestMismatch"
+
+// ErrDigestMismatch is returned, if the event digest does not match the event data.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+var digestMismatchHeader = kgo.RecordHeader{Key: HeaderDigestMismatch, Value: []byte("true")}
+
+// DigestVerifier recomputes the event digest from the event data like the qubic node. Mismatching events are published
+// with a flag header, sent to the dead letter queue or halt publishing, depending on the policy.
+type DigestVerifier struct {
+	policy      string
+	deadLetters *DeadLetterQueue
+	metrics     *Metrics
+}
+
+// NewDigestVerifier creates the verifier for the given policy. Returns nil for policy none. The dead letter policy
+// needs a dead letter queue.
+func NewDigestVerifier(policy string, deadLetters *DeadLetterQueue, metrics *Metrics) (*DigestVerifier, error) {
+	switch policy {
+	case PolicyNone:
+		return nil, nil
+	case PolicyFlag, PolicyHalt:
+	case PolicyDeadLetter:
+		if deadLetters == nil {
+			return nil, errors.New("digest policy dead letter needs a dead letter queue")
+		}
+	default:
+		return nil, errors.Errorf("unknown digest policy [%s]", policy)
+	}
+	return &DigestVerifier{
+		policy:      policy,
+		deadLetters: deadLetters,
+		metrics:     metrics,
+	}, nil
+}
+
+// verify returns an error, if the event digest does not match the digest of the event data.
+func (dv *DigestVerifier) verify(event *Event) error {
+	data, err := decodeEventData(event)
+	if err == nil {
+		digest := eventDigest(data)
+		if digest != event.EventDigest {
+			err = errors.Wrapf(ErrDigestMismatch, "event digest [%d], computed digest [%d]", event.EventDigest, digest)
+		}
+	}
+	if err != nil && dv.metrics != nil {
+		dv.metrics.IncDigestMismatches()
+	}
+	return err
+}
+
+// eventDigest returns the little endian uint64 of the 8 byte KangarooTwelve hash of the event data.
+func eventDigest(data []byte) uint64 {
+	var hash [8]byte
+	state := k12.NewDraft10(nil)
+	_, _ = state.Write(data)
+	_, _ = state.Read(hash[:])
+	return binary.LittleEndian.Uint64(hash[:])
+}
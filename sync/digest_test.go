@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"context"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEventDigest(t *testing.T) {
+	event := testTransferEvent()
+	data, err := decodeEventData(event)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1715952909454684526), eventDigest(data))
+}
+
+func TestDigestVerifier_verify(t *testing.T) {
+	verifier, err := NewDigestVerifier(PolicyFlag, nil, metrics)
+	require.NoError(t, err)
+
+	assert.NoError(t, verifier.verify(testTransferEvent()))
+
+	event := testTransferEvent()
+	event.EventDigest++
+	assert.ErrorIs(t, verifier.verify(event), ErrDigestMismatch)
+
+	event = testTransferEvent()
+	event.EventData = "not base64!"
+	assert.Error(t, verifier.verify(event))
+}
+
+func TestNewDigestVerifier(t *testing.T) {
+	verifier, err := NewDigestVerifier(PolicyNone, nil, metrics)
+	assert.NoError(t, err)
+	assert.Nil(t, verifier)
+
+	_, err = NewDigestVerifier(PolicyDeadLetter, nil, metrics)
+	assert.Error(t, err)
+
+	_, err = NewDigestVerifier("foo", nil, metrics)
+	assert.Error(t, err)
+}
+
+// digestTestTickEvents returns a valid event (id 1) and an event with digest mismatch (id 2).
+func digestTestTickEvents() *eventspb.TickEvents {
+	event := testTransferEvent()
+	return &eventspb.TickEvents{
+		Tick: event.Tick,
+		TxEvents: []*eventspb.TransactionEvents{
+			{
+				TxId: event.TransactionHash,
+				Events: []*eventspb.Event{
+					{Header: &eventspb.Event_Header{Epoch: 153, EventId: 1, EventDigest: event.EventDigest}, EventData: event.EventData},
+					{Header: &eventspb.Event_Header{Epoch: 153, EventId: 2, EventDigest: 42}, EventData: event.EventData},
+				},
+			},
+		},
+	}
+}
+
+func TestEventProducer_ProcessTickEvents_GivenDigestMismatchAndFlagPolicy_ThenPublishWithFlag(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	verifier, err := NewDigestVerifier(PolicyFlag, nil, metrics)
+	require.NoError(t, err)
+	pub := NewEventProducer(kafkaClient, WithDigestVerifier(verifier))
+
+	count, err := pub.ProcessTickEvents(context.Background(), 153, digestTestTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, kafkaClient.records, 2)
+	assert.Empty(t, headerValue(kafkaClient.records[0], HeaderDigestMismatch))
+	assert.Equal(t, "true", headerValue(kafkaClient.records[1], HeaderDigestMismatch))
+}
+
+func TestEventProducer_ProcessTickEvents_GivenDigestMismatchAndDeadLetterPolicy_ThenSendToDeadLetterTopic(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	verifier, err := NewDigestVerifier(PolicyDeadLetter, NewDeadLetterQueue(testDeadLetterTopic, metrics), metrics)
+	require.NoError(t, err)
+	pub := NewEventProducer(kafkaClient, WithTopicRouter(NewTopicRouter("topic", nil)), WithDigestVerifier(verifier))
+
+	count, err := pub.ProcessTickEvents(context.Background(), 153, digestTestTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, kafkaClient.records, 2)
+	assert.Equal(t, "topic", kafkaClient.records[0].Topic)
+	assert.Equal(t, testDeadLetterTopic, kafkaClient.records[1].Topic)
+	assert.Equal(t, "2", headerValue(kafkaClient.records[1], HeaderEventId))
+	assert.Contains(t, headerValue(kafkaClient.records[1], HeaderDeadLetterReason), "digest mismatch")
+}
+
+func TestEventProducer_ProcessTickEvents_GivenDigestMismatchAndHaltPolicy_ThenError(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	verifier, err := NewDigestVerifier(PolicyHalt, nil, metrics)
+	require.NoError(t, err)
+	pub := NewEventProducer(kafkaClient, WithDigestVerifier(verifier))
+
+	_, err = pub.ProcessTickEvents(context.Background(), 153, digestTestTickEvents())
+	assert.Error(t, err)
+	assert.Len(t, kafkaClient.records, 1)
+}
@@ -2,8 +2,10 @@ package sync
 
 import (
 	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/qubic/go-events-publisher/client"
+	"github.com/qubic/go-events-publisher/status"
 	eventspb "github.com/qubic/go-events/proto"
 	"log"
 	"time"
@@ -18,34 +20,169 @@ type EventProcessor struct {
 	eventPublisher Producer
 	dataStore      DataStore
 	syncMetrics    *Metrics
+	status         *status.Status
+	concurrency    int
+	gracePeriod    time.Duration
+	stopEpoch      uint32 // last epoch to process, 0 for no stop
+	stopTick       uint32 // last tick to process in the stop epoch, 0 for all ticks of the stop epoch
+
+	lastEpoch uint32 // last processed epoch and tick since start
+	lastTick  uint32
+}
+
+type ProcessorOption func(*EventProcessor)
+
+// WithStatus reports the health of the processing, for example stalled delivery, to the service status.
+func WithStatus(status *status.Status) ProcessorOption {
+	return func(r *EventProcessor) {
+		r.status = status
+	}
+}
+
+// WithPrefetch limits the ticks in flight (fetching, waiting or publishing) to concurrency, so that up to
+// concurrency-1 ticks are fetched ahead, while the current tick is published. The ticks are still published and
+// checkpointed in tick order. Defaults to 1, fetching one tick after the other.
+func WithPrefetch(concurrency int) ProcessorOption {
+	return func(r *EventProcessor) {
+		r.concurrency = concurrency
+	}
 }
 
-func NewEventProcessor(client Client, publisher Producer, store DataStore, metrics *Metrics) *EventProcessor {
+// WithShutdownGracePeriod sets the time the current tick has for delivery after the sync context is done. Defaults to
+// aborting the current tick immediately.
+func WithShutdownGracePeriod(gracePeriod time.Duration) ProcessorOption {
+	return func(r *EventProcessor) {
+		r.gracePeriod = gracePeriod
+	}
+}
+
+// WithStop stops processing after the given epoch and tick (inclusive). If tick is 0 all ticks of the epoch are
+// processed. Defaults to processing all epochs.
+func WithStop(epoch, tick uint32) ProcessorOption {
+	return func(r *EventProcessor) {
+		r.stopEpoch = epoch
+		r.stopTick = tick
+	}
+}
+
+func NewEventProcessor(client Client, publisher Producer, store DataStore, metrics *Metrics, options ...ProcessorOption) *EventProcessor {
 	es := EventProcessor{
 		eventClient:    client,
 		eventPublisher: publisher,
 		dataStore:      store,
 		syncMetrics:    metrics,
+		concurrency:    1,
+	}
+	for _, option := range options {
+		option(&es)
 	}
 	return &es
 }
 
-func (r *EventProcessor) SyncInLoop(startEpoch uint32) {
+// SyncInLoop syncs until the context is done. Then no new ticks are fetched and the current tick is completed within
+// the shutdown grace period.
+func (r *EventProcessor) SyncInLoop(ctx context.Context, startEpoch uint32) {
 	epoch := startEpoch
-	loopTick := time.Tick(time.Second * 1)
-	for range loopTick {
-		latestProcessedEpoch, err := r.sync(epoch)
+	for {
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			r.logStopped()
+			return
+		}
+		latestProcessedEpoch, err := r.sync(ctx, epoch)
 		if err != nil {
-			log.Printf("sync run failed: %v", err)
+			if ctx.Err() != nil {
+				log.Printf("sync run interrupted by shutdown: %v", err)
+			} else {
+				log.Printf("sync run failed: %v", err)
+			}
 		}
 		epoch = latestProcessedEpoch
-		time.Sleep(time.Second)
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
 	}
 }
 
-func (r *EventProcessor) sync(startEpoch uint32) (uint32, error) {
-	ctx := context.Background()
+// SyncToCompletion syncs until all ticks up to the stop epoch and tick are processed. Returns an error, if processing
+// fails or the context is done before.
+func (r *EventProcessor) SyncToCompletion(ctx context.Context, startEpoch uint32) error {
+	if r.stopEpoch == 0 {
+		return errors.New("run to completion needs a stop epoch")
+	}
+	defer func() {
+		if ctx.Err() != nil {
+			r.logStopped()
+		}
+	}()
+	epoch := startEpoch
+	for {
+		lastTick := r.lastTick
+		latestProcessedEpoch, err := r.sync(ctx, epoch)
+		if err != nil {
+			return err
+		}
+		epoch = latestProcessedEpoch
 
+		completed, err := r.completed(ctx, startEpoch)
+		if err != nil {
+			return errors.Wrap(err, "checking completion")
+		}
+		if completed {
+			log.Printf("Completed syncing up to epoch [%d] tick [%d]. Last processed tick [%d] in epoch [%d].", r.stopEpoch, r.stopTick, r.lastTick, r.lastEpoch)
+			return nil
+		}
+		if r.lastTick != lastTick {
+			continue // ticks processed. Don't wait for the next range.
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// completed returns true, if there are no ticks left to process and the stop tick is reached or the stop epoch is
+// over.
+func (r *EventProcessor) completed(ctx context.Context, startEpoch uint32) (bool, error) {
+	// get status first. If the stop epoch is over, its tick intervals are final.
+	eventStatus, err := r.eventClient.GetStatus(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "calling event service")
+	}
+	start, _, _, err := r.calculateTickRange(ctx, startEpoch)
+	if err != nil {
+		return false, errors.Wrap(err, "calculating tick range")
+	}
+	if start > 0 {
+		return false, nil
+	}
+	if eventStatus.Epoch > r.stopEpoch {
+		return true, nil
+	}
+	if r.stopTick == 0 {
+		return false, nil
+	}
+	lastProcessedTick, err := r.dataStore.GetLastProcessedTick(r.stopEpoch)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return false, errors.Wrap(err, "getting last processed tick")
+	}
+	return lastProcessedTick >= r.stopTick, nil
+}
+
+func (r *EventProcessor) logStopped() {
+	if r.lastTick == 0 {
+		log.Printf("Stopped syncing. No tick processed.")
+		return
+	}
+	log.Printf("Stopped syncing. Last processed tick [%d] in epoch [%d].", r.lastTick, r.lastEpoch)
+}
+
+func (r *EventProcessor) sync(ctx context.Context, startEpoch uint32) (uint32, error) {
 	start, end, epoch, err := r.calculateTickRange(ctx, startEpoch)
 	if err != nil {
 		return startEpoch, errors.Wrap(err, "Error calculating tick range")
@@ -65,8 +202,19 @@ func (r *EventProcessor) sync(startEpoch uint32) (uint32, error) {
 }
 
 func (r *EventProcessor) processTickEventsRange(ctx context.Context, epoch, from, toExcl uint32) error {
+	publishCtx, cancelPublish := r.drainContext(ctx)
+	defer cancelPublish()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops prefetching on error
+
+	prefetcher := startTickPrefetcher(ctx, r.eventClient, r.syncMetrics, r.concurrency, from, toExcl)
 	for tick := from; tick < toExcl; tick++ {
-		err := r.processTickEvents(ctx, tick)
+		fetched, ok := prefetcher.next(ctx)
+		if !ok || ctx.Err() != nil {
+			return errors.Wrapf(ctx.Err(), "fetching tick [%d]", tick)
+		}
+		err := r.publishTickEvents(publishCtx, epoch, fetched)
+		prefetcher.release()
 		if err != nil {
 			return errors.Wrapf(err, "processing tick [%d]", tick)
 		}
@@ -76,36 +224,63 @@ func (r *EventProcessor) processTickEventsRange(ctx context.Context, epoch, from
 		if err != nil {
 			return errors.Wrapf(err, "setting last processed tick [%d]", tick)
 		}
+		r.lastEpoch, r.lastTick = epoch, tick
 	}
 	return nil
 }
 
-func (r *EventProcessor) processTickEvents(ctx context.Context, tick uint32) error {
+// drainContext returns the context for publishing. It is cancelled only after the grace period, if the given context
+// is done, so that the current tick can be delivered.
+func (r *EventProcessor) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(r.gracePeriod, cancel)
+	})
+	return drainCtx, func() {
+		stop()
+		cancel()
+	}
+}
 
+func (r *EventProcessor) publishTickEvents(ctx context.Context, epoch uint32, fetched fetchedTick) error {
+	tick := fetched.tick
 	log.Printf("Processing tick [%d].", tick)
 
-	first := time.Now().UnixMilli()
-	tickEvents, err := r.eventClient.GetEvents(ctx, tick)
-	if err != nil {
-		return errors.Wrap(err, "getting events")
+	if fetched.err != nil {
+		return errors.Wrap(fetched.err, "getting events")
 	}
 
-	second := time.Now().UnixMilli()
-	count, err := r.eventPublisher.ProcessTickEvents(ctx, tickEvents)
+	count, err := r.eventPublisher.ProcessTickEvents(ctx, epoch, fetched.tickEvents)
 	if err != nil {
+		if errors.Is(err, ErrDeliveryTimeout) {
+			r.syncMetrics.IncDeliveryTimeouts()
+			r.setDegraded(fmt.Sprintf("delivery of tick [%d] timed out", tick))
+		}
 		return errors.Wrapf(err, "processing events")
 	}
+	r.setUp()
 
 	if count > 0 {
 		r.syncMetrics.AddProcessedMessages(count)
-		end := time.Now().UnixMilli()
-		total := end - first
-		serviceCall := second - first
+		total := time.Since(fetched.start).Milliseconds()
+		serviceCall := fetched.read.Milliseconds()
 		log.Printf("Processed [%d] events in %dms (read: %dms)", count, total, serviceCall)
 	}
 	return nil
 }
 
+func (r *EventProcessor) setUp() {
+	if r.status != nil {
+		r.status.SetUp()
+	}
+}
+
+func (r *EventProcessor) setDegraded(reason string) {
+	if r.status != nil {
+		r.status.SetDegraded(reason)
+	}
+}
+
 func (r *EventProcessor) calculateTickRange(ctx context.Context, startEpoch uint32) (uint32, uint32, uint32, error) {
 
 	// get status from event service
@@ -117,9 +292,13 @@ func (r *EventProcessor) calculateTickRange(ctx context.Context, startEpoch uint
 
 	// find first tick that is not stored yet
 	searchEpoch := min(startEpoch, eventStatus.Epoch)
+	lastEpoch := eventStatus.Epoch
+	if r.stopEpoch > 0 {
+		lastEpoch = min(lastEpoch, r.stopEpoch)
+	}
 
 	// find first tick interval to process
-	for searchEpoch <= eventStatus.Epoch {
+	for searchEpoch <= lastEpoch {
 		tickIntervals := eventStatus.Intervals[searchEpoch]
 		if tickIntervals == nil {
 			// nothing to sync
@@ -133,10 +312,13 @@ func (r *EventProcessor) calculateTickRange(ctx context.Context, startEpoch uint
 			}
 
 			for _, tickInterval := range tickIntervals {
-				if tickInterval.To > lastProcessedTick {
+				end := tickInterval.To
+				if searchEpoch == r.stopEpoch && r.stopTick > 0 {
+					end = min(end, r.stopTick)
+				}
+				if end > lastProcessedTick && end >= tickInterval.From {
 					// ok process
 					start := max(tickInterval.From, lastProcessedTick+1)
-					end := tickInterval.To
 					return start, end, searchEpoch, nil
 				}
 			}
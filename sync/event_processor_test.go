@@ -1,14 +1,19 @@
 package sync
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"github.com/qubic/go-events-publisher/client"
+	"github.com/qubic/go-events-publisher/status"
 	eventspb "github.com/qubic/go-events/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"log"
 	"os"
 	"testing"
+	"time"
 )
 
 var store *PebbleStore
@@ -31,7 +36,7 @@ type FakeEventProcessor struct {
 	processedCount int
 }
 
-func (p *FakeEventProcessor) ProcessTickEvents(_ context.Context, tickEvents *eventspb.TickEvents) (int, error) {
+func (p *FakeEventProcessor) ProcessTickEvents(_ context.Context, _ uint32, tickEvents *eventspb.TickEvents) (int, error) {
 	if tickEvents == nil {
 		p.processedCount++
 	} else {
@@ -58,19 +63,19 @@ func TestEventProcessor_sync(t *testing.T) {
 
 	eventProcessor := FakeEventProcessor{}
 	reader := NewEventProcessor(eventClient, &eventProcessor, store, metrics)
-	epoch, err := reader.sync(115)
+	epoch, err := reader.sync(context.Background(), 115)
 	assert.NoError(t, err)
 	assert.Equal(t, 120, int(epoch))
 
 	assert.Equal(t, 4, eventProcessor.processedCount) // 4 ticks
 
-	epoch, err = reader.sync(120)
+	epoch, err = reader.sync(context.Background(), 120)
 	assert.NoError(t, err)
 	assert.Equal(t, 120, int(epoch))
 
 	assert.Equal(t, 5, eventProcessor.processedCount) // 1 tick
 
-	epoch, err = reader.sync(120)
+	epoch, err = reader.sync(context.Background(), 120)
 	assert.NoError(t, err)
 	assert.Equal(t, 123, int(epoch))
 
@@ -137,6 +142,204 @@ func TestEventProcessor_calculateTickRanges(t *testing.T) {
 
 }
 
+func testEventStatus() *client.EventStatus {
+	return &client.EventStatus{
+		Epoch: 123,
+		Tick:  12345,
+		Intervals: map[uint32][]*client.ProcessedTickInterval{
+			120: {{From: 1230, To: 1233}, {From: 1234, To: 1234}},
+			123: {{From: 12340, To: 12345}},
+		},
+	}
+}
+
+func TestEventProcessor_calculateTickRange_GivenStop_ThenLimitRange(t *testing.T) {
+	eventClient := &FakeEventClient{status: testEventStatus()}
+	reader := NewEventProcessor(eventClient, &FakeEventProcessor{}, store, metrics, WithStop(120, 1232))
+
+	start, end, epoch, err := reader.calculateTickRange(context.Background(), 120)
+	assert.NoError(t, err)
+	assert.Equal(t, 120, int(epoch))
+	assert.Equal(t, 1230, int(start))
+	assert.Equal(t, 1232, int(end))
+
+	err = reader.dataStore.SetLastProcessedTick(120, 1232)
+	assert.NoError(t, err)
+
+	start, end, epoch, err = reader.calculateTickRange(context.Background(), 120)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, int(epoch))
+	assert.Equal(t, 0, int(start))
+	assert.Equal(t, 0, int(end))
+
+	// clean up
+	err = reader.dataStore.deleteLastProcessedTicks(120, 124)
+	assert.NoError(t, err)
+}
+
+func TestEventProcessor_SyncToCompletion(t *testing.T) {
+	tests := []struct {
+		name      string
+		stopEpoch uint32
+		stopTick  uint32
+		expected  int // processed ticks
+	}{
+		{name: "stop epoch over", stopEpoch: 120, expected: 5},
+		{name: "stop tick reached", stopEpoch: 123, stopTick: 12342, expected: 8},
+		{name: "stop tick after epoch end", stopEpoch: 120, stopTick: 1240, expected: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventClient := &FakeEventClient{status: testEventStatus(), events: map[uint32]*eventspb.TickEvents{}}
+			producer := &FakeEventProcessor{}
+			reader := NewEventProcessor(eventClient, producer, store, metrics, WithStop(tt.stopEpoch, tt.stopTick))
+
+			err := reader.SyncToCompletion(context.Background(), 120)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, producer.processedCount)
+
+			// clean up
+			err = reader.dataStore.deleteLastProcessedTicks(120, 124)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestEventProcessor_SyncToCompletion_GivenNoStopEpoch_ThenError(t *testing.T) {
+	reader := NewEventProcessor(&FakeEventClient{status: testEventStatus()}, &FakeEventProcessor{}, store, metrics)
+	assert.Error(t, reader.SyncToCompletion(context.Background(), 120))
+}
+
+func TestEventProcessor_SyncToCompletion_GivenProcessingError_ThenError(t *testing.T) {
+	eventClient := &FakeEventClient{status: testEventStatus(), events: map[uint32]*eventspb.TickEvents{}}
+	reader := NewEventProcessor(eventClient, &FailingEventProcessor{err: errors.New("test error")}, store, metrics, WithStop(120, 0))
+	assert.ErrorContains(t, reader.SyncToCompletion(context.Background(), 120), "test error")
+}
+
+func TestEventProcessor_SyncToCompletion_GivenShutdown_ThenLogLastProcessedTick(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	eventClient := &FakeEventClient{status: testEventStatus(), events: map[uint32]*eventspb.TickEvents{}}
+	producer := &BlockingEventProcessor{started: make(chan uint32, 2), delivery: 10 * time.Millisecond}
+	reader := NewEventProcessor(eventClient, producer, store, metrics, WithStop(120, 0), WithShutdownGracePeriod(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-producer.started
+		cancel()
+	}()
+	err := reader.SyncToCompletion(ctx, 120)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, logs.String(), "Stopped syncing. Last processed tick [1230] in epoch [120].")
+
+	// clean up
+	err = store.deleteLastProcessedTicks(120, 121)
+	assert.NoError(t, err)
+}
+
+type FailingEventProcessor struct {
+	err error
+}
+
+func (p *FailingEventProcessor) ProcessTickEvents(_ context.Context, _ uint32, _ *eventspb.TickEvents) (int, error) {
+	return 0, p.err
+}
+
+func TestEventProcessor_publishTickEvents_GivenDeliveryTimeout_ThenDegraded(t *testing.T) {
+	eventClient := &FakeEventClient{events: map[uint32]*eventspb.TickEvents{}}
+	serviceStatus := status.NewStatus()
+
+	producer := &FailingEventProcessor{err: ErrDeliveryTimeout}
+	reader := NewEventProcessor(eventClient, producer, store, metrics, WithStatus(serviceStatus))
+	err := reader.publishTickEvents(context.Background(), 123, fetchedTick{tick: 42, tickEvents: &eventspb.TickEvents{Tick: 42}})
+	assert.ErrorIs(t, err, ErrDeliveryTimeout)
+	state, reason := serviceStatus.Get()
+	assert.Equal(t, status.Degraded, state)
+	assert.Contains(t, reason, "42")
+
+	producer.err = nil
+	err = reader.publishTickEvents(context.Background(), 123, fetchedTick{tick: 43, tickEvents: &eventspb.TickEvents{Tick: 43}})
+	assert.NoError(t, err)
+	state, _ = serviceStatus.Get()
+	assert.Equal(t, status.Up, state)
+}
+
+// BlockingEventProcessor signals the start of publishing and waits for the delivery or until the context is done.
+type BlockingEventProcessor struct {
+	started  chan uint32
+	delivery time.Duration
+}
+
+func (p *BlockingEventProcessor) ProcessTickEvents(ctx context.Context, _ uint32, tickEvents *eventspb.TickEvents) (int, error) {
+	p.started <- tickEvents.GetTick()
+	select {
+	case <-time.After(p.delivery):
+		return 1, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func TestEventProcessor_processTickEventsRange_GivenShutdown_ThenCompleteCurrentTick(t *testing.T) {
+	eventClient := &FakeEventClient{events: map[uint32]*eventspb.TickEvents{1230: {Tick: 1230}, 1231: {Tick: 1231}}}
+	producer := &BlockingEventProcessor{started: make(chan uint32, 2), delivery: 50 * time.Millisecond}
+	reader := NewEventProcessor(eventClient, producer, store, metrics, WithShutdownGracePeriod(time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-producer.started
+		cancel()
+	}()
+	err := reader.processTickEventsRange(ctx, 120, 1230, 1232)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, producer.started) // next tick not started
+
+	lastProcessedTick, err := store.GetLastProcessedTick(120)
+	require.NoError(t, err)
+	assert.Equal(t, 1230, int(lastProcessedTick))
+	assert.Equal(t, 1230, int(reader.lastTick))
+
+	// clean up
+	err = store.deleteLastProcessedTicks(120, 121)
+	assert.NoError(t, err)
+}
+
+func TestEventProcessor_processTickEventsRange_GivenShutdownAndGracePeriodExceeded_ThenAbortCurrentTick(t *testing.T) {
+	eventClient := &FakeEventClient{events: map[uint32]*eventspb.TickEvents{1230: {Tick: 1230}}}
+	producer := &BlockingEventProcessor{started: make(chan uint32, 1), delivery: time.Minute}
+	reader := NewEventProcessor(eventClient, producer, store, metrics, WithShutdownGracePeriod(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-producer.started
+		cancel()
+	}()
+	err := reader.processTickEventsRange(ctx, 120, 1230, 1231)
+	assert.ErrorContains(t, err, "processing tick [1230]")
+
+	_, err = store.GetLastProcessedTick(120)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestEventProcessor_SyncInLoop_GivenCancelledContext_ThenReturn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reader := NewEventProcessor(&FakeEventClient{}, &FakeEventProcessor{}, store, metrics)
+
+	done := make(chan struct{})
+	go func() {
+		reader.SyncInLoop(ctx, 120)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sync loop did not stop")
+	}
+}
+
 //goland:noinspection GoUnhandledErrorResult
 func TestMain(m *testing.M) {
 
@@ -2,13 +2,14 @@ package sync
 
 import (
 	"context"
-	"encoding/binary"
-	"encoding/json"
 	"github.com/pkg/errors"
+	"github.com/qubic/go-events-publisher/payload"
 	eventspb "github.com/qubic/go-events/proto"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"log"
+	"slices"
 	"sync"
+	"time"
 )
 
 type Event struct {
@@ -20,10 +21,11 @@ type Event struct {
 	EventType       uint32 `json:"eventType"`
 	EventSize       uint32 `json:"eventSize"`
 	EventData       string `json:"eventData"`
+	Payload         any    `json:"payload,omitempty"` // decoded event data, if available for the event type
 }
 
 type Producer interface {
-	ProcessTickEvents(ctx context.Context, tickEvents *eventspb.TickEvents) (int, error)
+	ProcessTickEvents(ctx context.Context, epoch uint32, tickEvents *eventspb.TickEvents) (int, error)
 }
 
 type KafkaClient interface {
@@ -31,70 +33,428 @@ type KafkaClient interface {
 }
 
 type EventProducer struct {
-	kcl KafkaClient
+	kcl         KafkaClient
+	keyStrategy KeyStrategy
+	headers     *RecordHeaders
+	router      *TopicRouter
+	deadLetters *DeadLetterQueue
+	tickTimeout time.Duration
+	markerTopic string
+	serializers *Serializers
+	cloudEvents *CloudEvents
+	decode      bool
+	metrics     *Metrics // for payload decode errors
+	digests     *DigestVerifier
+	ticks       *TickAggregation
+
+	transactionTopic string
+
+	migrationTopic      string
+	migrationSerializer Serializer
+}
+
+// ErrDeliveryTimeout is returned, if the records of a tick could not be delivered in time.
+var ErrDeliveryTimeout = errors.New("delivery timeout")
+
+type ProducerOption func(*EventProducer)
+
+// WithKeyStrategy sets the strategy for creating record keys. Defaults to keying by tick.
+func WithKeyStrategy(keyStrategy KeyStrategy) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.keyStrategy = keyStrategy
+	}
+}
+
+// WithRecordHeaders adds metadata headers to the event records. Defaults to no headers.
+func WithRecordHeaders(headers *RecordHeaders) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.headers = headers
+	}
+}
+
+// WithTopicRouter routes the event records to topics depending on the event type. Defaults to the default produce
+// topic of the kafka client.
+func WithTopicRouter(router *TopicRouter) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.router = router
+	}
+}
+
+// WithDeadLetterQueue sends events that cannot be serialized or that are rejected by kafka to the dead letter queue.
+// Defaults to halting on such events.
+func WithDeadLetterQueue(deadLetters *DeadLetterQueue) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.deadLetters = deadLetters
+	}
+}
+
+// WithTickDeliveryTimeout sets the maximum time for delivering all records of one tick. Defaults to no timeout.
+func WithTickDeliveryTimeout(timeout time.Duration) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.tickTimeout = timeout
+	}
 }
 
-func NewEventProducer(client KafkaClient) *EventProducer {
-	return &EventProducer{
-		kcl: client,
+// WithTickMarkers publishes a tick marker record to the given topic after all events of a tick are published.
+// Defaults to no markers.
+func WithTickMarkers(topic string) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.markerTopic = topic
 	}
 }
 
-func (ep *EventProducer) ProcessTickEvents(_ context.Context, tickEvents *eventspb.TickEvents) (int, error) {
-	var sentEvents int
+// WithSerializers sets the wire format per topic. Defaults to json for all topics.
+func WithSerializers(serializers *Serializers) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.serializers = serializers
+	}
+}
+
+// WithCloudEvents wraps the event records as cloud events. Defaults to plain event records.
+func WithCloudEvents(cloudEvents *CloudEvents) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.cloudEvents = cloudEvents
+	}
+}
+
+// WithPayloadDecoding adds the decoded event data as payload to the events. Events that cannot be decoded are published
+// without payload and counted as payload decode errors. Defaults to no decoding.
+func WithPayloadDecoding(metrics *Metrics) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.decode = true
+		ep.metrics = metrics
+	}
+}
+
+// WithDigestVerifier verifies the event digests before publishing. Defaults to no verification.
+func WithDigestVerifier(verifier *DigestVerifier) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.digests = verifier
+	}
+}
+
+// WithMigrationTopic additionally publishes all events to the migration topic using the given serializer. Used to
+// publish two schema versions side by side during migrations.
+func WithMigrationTopic(topic string, serializer Serializer) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.migrationTopic = topic
+		ep.migrationSerializer = serializer
+	}
+}
+
+// WithTickAggregation additionally publishes the events of each tick as one record to the tick topic. Defaults to no
+// tick records.
+func WithTickAggregation(ticks *TickAggregation) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.ticks = ticks
+	}
+}
+
+// WithTransactionTopic additionally publishes one message per transaction with all events of the transaction to the
+// given topic. Defaults to no transaction messages.
+func WithTransactionTopic(topic string) ProducerOption {
+	return func(ep *EventProducer) {
+		ep.transactionTopic = topic
+	}
+}
+
+func NewEventProducer(client KafkaClient, options ...ProducerOption) *EventProducer {
+	ep := EventProducer{
+		kcl:         client,
+		keyStrategy: TickKeyStrategy{},
+	}
+	for _, option := range options {
+		option(&ep)
+	}
+	return &ep
+}
+
+func (ep *EventProducer) ProcessTickEvents(ctx context.Context, epoch uint32, tickEvents *eventspb.TickEvents) (int, error) {
+	var results deliveryResults
 	tick := tickEvents.Tick
 	wg := sync.WaitGroup{}
 
-	var errs []error // TODO replace this with an channel. see data-publisher transactions publisher
+	if ep.tickTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ep.tickTimeout)
+		defer cancel()
+	}
+
+	var unserializable, mismatched []*kgo.Record
+	var producedEventIds []uint64 // for the tick marker
+	var aggregatedEvents []tickEvent
 	for _, transactionEvents := range tickEvents.TxEvents {
 		transactionHash := transactionEvents.TxId
 		// log.Printf("Processing events of transaction [%s]: [%d].", transactionHash, len(transactionEvents.Events))
+		var bundledEvents []*Event
+		var bundleMismatch bool
 
 		for _, e := range transactionEvents.Events {
 
 			eventId := e.Header.EventId
-			record, err := createEventRecord(e, tick, transactionHash)
+			event := createEvent(e, tick, transactionHash)
+			var digestMismatch bool
+			if ep.digests != nil {
+				err := ep.digests.verify(&event)
+				if err != nil {
+					verifyError := errors.Wrapf(err, "verifying digest for tick [%d] transaction [%s] event [%d]", tick, transactionHash, eventId)
+					log.Printf("Error %v", verifyError)
+					if ep.digests.policy == PolicyDeadLetter {
+						mismatched = append(mismatched, ep.digests.deadLetters.createRecord(&event, nil, verifyError))
+						continue
+					}
+					if ep.digests.policy == PolicyHalt {
+						results.addError(verifyError)
+						break
+					}
+					digestMismatch = true
+				}
+			}
+
+			records, err := ep.createEventRecords(&event)
 			if err != nil {
 				createError := errors.Wrapf(err, "creating message for tick [%d] transaction [%s] event [%d]", tick, transactionHash, eventId)
 				log.Printf("Error %v", createError)
-				errs = append(errs, createError)
+				if ep.deadLetters != nil {
+					unserializable = append(unserializable, ep.deadLetters.createRecord(&event, nil, createError))
+					continue
+				}
+				results.addError(createError)
 				break
 			}
+			bundledEvents = append(bundledEvents, &event)
+			producedEventIds = append(producedEventIds, eventId)
+			bundleMismatch = bundleMismatch || digestMismatch
+			if ep.ticks.aggregates(event.EventType) {
+				aggregatedEvents = append(aggregatedEvents, tickEvent{event: &event, digestMismatch: digestMismatch})
+			}
+			for i, record := range records {
+				if digestMismatch {
+					record.Headers = append(record.Headers, digestMismatchHeader)
+				}
+				countEvent := i == 0 // don't count migration records
 
+				wg.Add(1)
+				ep.kcl.Produce(ctx, record, func(r *kgo.Record, err error) {
+					defer wg.Done()
+					if err != nil {
+						sendError := errors.Wrapf(err, "sending message for tick [%d] transaction [%s] event [%d]", tick, transactionHash, eventId)
+						log.Printf("Error %v", sendError)
+						if ep.deadLetters != nil && isUnpublishable(err) {
+							results.addRejected(ep.deadLetters.createRecord(&event, r, sendError))
+							if countEvent {
+								results.addUnpublished(eventId)
+							}
+						} else {
+							results.addError(sendError)
+						}
+					} else if countEvent {
+						results.addSent(1)
+					}
+				})
+			}
+			// Be aware: if the producer has no information if the message was delivered (like network down) it can
+			// hang here until the network is back up. Use the tick delivery timeout to limit waiting.
+		}
+
+		if ep.transactionTopic != "" && transactionHash != "" && len(bundledEvents) > 0 && !results.failed() {
+			record, err := ep.createTransactionRecord(epoch, tick, transactionHash, bundledEvents, bundleMismatch)
+			if err != nil {
+				createError := errors.Wrapf(err, "creating transaction message for tick [%d] transaction [%s]", tick, transactionHash)
+				log.Printf("Error %v", createError)
+				results.addError(createError)
+			} else {
+				wg.Add(1)
+				ep.kcl.Produce(ctx, record, func(_ *kgo.Record, err error) {
+					defer wg.Done()
+					if err != nil {
+						sendError := errors.Wrapf(err, "sending transaction message for tick [%d] transaction [%s]", tick, transactionHash)
+						log.Printf("Error %v", sendError)
+						results.addError(sendError)
+					}
+				})
+			}
+		}
+
+		// in case we encounter an error don't proceed with next transaction
+		if results.failed() {
+			log.Printf("Aborting sending events for tick [%d] because of error(s).", tick)
+			break
+		}
+
+	}
+
+	if !results.failed() && len(aggregatedEvents) > 0 {
+		records, err := ep.createTickRecords(epoch, tick, aggregatedEvents)
+		if err != nil {
+			createError := errors.Wrapf(err, "creating tick records for tick [%d]", tick)
+			log.Printf("Error %v", createError)
+			results.addError(createError)
+		}
+		for i, record := range records {
 			wg.Add(1)
-			ep.kcl.Produce(nil, record, func(_ *kgo.Record, err error) {
+			ep.kcl.Produce(ctx, record, func(_ *kgo.Record, err error) {
 				defer wg.Done()
 				if err != nil {
-					sendError := errors.Wrapf(err, "sending message for tick [%d] transaction [%s] event [%d]", tick, transactionHash, eventId)
+					sendError := errors.Wrapf(err, "sending tick record [%d/%d] for tick [%d]", i+1, len(records), tick)
 					log.Printf("Error %v", sendError)
-					errs = append(errs, sendError)
-				} else {
-					sentEvents++
+					results.addError(sendError)
 				}
 			})
-			// Be aware: if the producer has no information if the message was delivered (like network down) it will hang
-			// here indefinitely until the network is back up. No error will be produced in this case.
 		}
+	}
 
-		// in case we encounter an error don't proceed with next transaction
-		if len(errs) > 0 {
-			log.Printf("Aborting sending events for tick [%d] because of error(s).", tick)
-			break
+	// wait at end of tick (performance vs. error handling)
+	err := waitForDelivery(ctx, &wg)
+	if err != nil {
+		return 0, errors.Wrapf(err, "waiting for delivery of tick [%d]", tick)
+	}
+	// all promises are called
+	sentEvents := results.sentEvents
+	if errs := results.errs; len(errs) > 0 {
+		if slices.ContainsFunc(errs, isDeliveryTimeout) {
+			return sentEvents, errors.Wrapf(ErrDeliveryTimeout, "[%d] error(s) sending messages for tick [%d]", len(errs), tick)
 		}
+		return sentEvents, errors.Errorf("[%d] error(s) sending messages for tick [%d]", len(errs), tick)
+	}
 
+	deadLetters := append(unserializable, results.rejected...)
+	if len(deadLetters) > 0 {
+		err := ep.produceDeadLetters(ctx, ep.deadLetters, deadLetters)
+		if err != nil {
+			return sentEvents, errors.Wrapf(err, "sending dead letters for tick [%d]", tick)
+		}
+	}
+	if len(mismatched) > 0 {
+		err := ep.produceDeadLetters(ctx, ep.digests.deadLetters, mismatched)
+		if err != nil {
+			return sentEvents, errors.Wrapf(err, "sending digest mismatch dead letters for tick [%d]", tick)
+		}
 	}
 
-	// wait at end of tick (performance vs. error handling)
-	wg.Wait()
-	if len(errs) > 0 {
-		return sentEvents, errors.Errorf("[%d] error(s) sending messages for tick [%d]", len(errs), tick)
+	if ep.markerTopic != "" {
+		publishedEventIds := slices.DeleteFunc(producedEventIds, func(eventId uint64) bool {
+			return results.unpublished[eventId]
+		})
+		marker := createTickMarker(epoch, tick, publishedEventIds, len(deadLetters)+len(mismatched), time.Now())
+		err := ep.produceTickMarker(ctx, marker)
+		if err != nil {
+			return sentEvents, errors.Wrapf(err, "sending tick marker for tick [%d]", tick)
+		}
 	}
 
 	return sentEvents, nil
 }
 
-func createEventRecord(sourceEvent *eventspb.Event, tick uint32, transactionHash string) (*kgo.Record, error) {
-	event := Event{
+func (ep *EventProducer) produceDeadLetters(ctx context.Context, deadLetters *DeadLetterQueue, records []*kgo.Record) error {
+	err := ep.produceAll(ctx, records)
+	if err != nil {
+		return err
+	}
+	deadLetters.addDeadLetters(len(records))
+	log.Printf("Sent [%d] dead letter(s) to topic [%s].", len(records), deadLetters.topic)
+	return nil
+}
+
+func (ep *EventProducer) produceTickMarker(ctx context.Context, marker TickMarker) error {
+	record, err := createTickMarkerRecord(ep.markerTopic, marker)
+	if err != nil {
+		return err
+	}
+	return ep.produceAll(ctx, []*kgo.Record{record})
+}
+
+// produceAll produces the records and waits for delivery.
+func (ep *EventProducer) produceAll(ctx context.Context, records []*kgo.Record) error {
+	wg := sync.WaitGroup{}
+	var results deliveryResults
+	for _, record := range records {
+		wg.Add(1)
+		ep.kcl.Produce(ctx, record, func(_ *kgo.Record, err error) {
+			defer wg.Done()
+			if err != nil {
+				results.addError(err)
+			}
+		})
+	}
+	err := waitForDelivery(ctx, &wg)
+	if err != nil {
+		return err
+	}
+	if errs := results.errs; len(errs) > 0 {
+		return errors.Wrapf(errs[0], "[%d] error(s) sending records", len(errs))
+	}
+	return nil
+}
+
+// deliveryResults collects the results of the produce promises. The kafka client calls the promises concurrently.
+type deliveryResults struct {
+	mutex       sync.Mutex
+	errs        []error
+	rejected    []*kgo.Record
+	unpublished map[uint64]bool // ids of rejected events
+	sentEvents  int
+}
+
+func (dr *deliveryResults) addError(err error) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+	dr.errs = append(dr.errs, err)
+}
+
+func (dr *deliveryResults) addRejected(record *kgo.Record) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+	dr.rejected = append(dr.rejected, record)
+}
+
+func (dr *deliveryResults) addUnpublished(eventId uint64) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+	if dr.unpublished == nil {
+		dr.unpublished = map[uint64]bool{}
+	}
+	dr.unpublished[eventId] = true
+}
+
+func (dr *deliveryResults) addSent(count int) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+	dr.sentEvents += count
+}
+
+// failed returns true, if there is an error so far.
+func (dr *deliveryResults) failed() bool {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+	return len(dr.errs) > 0
+}
+
+// waitForDelivery waits until all promises are called or until the context is done.
+func waitForDelivery(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrDeliveryTimeout
+		}
+		return ctx.Err()
+	}
+}
+
+func isDeliveryTimeout(err error) bool {
+	return errors.Is(err, kgo.ErrRecordTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func createEvent(sourceEvent *eventspb.Event, tick uint32, transactionHash string) Event {
+	return Event{
 		Epoch:           sourceEvent.Header.Epoch,
 		Tick:            tick,
 		EventId:         sourceEvent.Header.EventId,
@@ -104,15 +464,94 @@ func createEventRecord(sourceEvent *eventspb.Event, tick uint32, transactionHash
 		EventSize:       sourceEvent.EventSize,
 		EventData:       sourceEvent.EventData,
 	}
+}
 
-	payload, err := json.Marshal(event)
+func (ep *EventProducer) createEventRecord(event *Event) (*kgo.Record, error) {
+	topic := ep.router.Topic(event.EventType)
+	serializer := ep.serializers.get(topic)
+	if ep.needsPayload(event, serializer) {
+		ep.decodeEvent(event)
+	}
+	return ep.createRecord(event, topic, serializer)
+}
+
+// needsPayload returns true, if one of the messages of the event contains the payload. Only json messages have a
+// payload field, protobuf and avro messages don't.
+func (ep *EventProducer) needsPayload(event *Event, serializer Serializer) bool {
+	_, isJson := serializer.(JsonSerializer)
+	_, migrationIsJson := ep.migrationSerializer.(JsonSerializer)
+	return isJson || (ep.migrationTopic != "" && migrationIsJson) || ep.ticks.aggregates(event.EventType)
+}
+
+// createEventRecords creates the event record and, if there is a migration topic, the record with the migration
+// schema version.
+func (ep *EventProducer) createEventRecords(event *Event) ([]*kgo.Record, error) {
+	record, err := ep.createEventRecord(event)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to marshal event")
+		return nil, err
+	}
+	records := []*kgo.Record{record}
+	if ep.migrationTopic == "" {
+		return records, nil
 	}
+	migrationRecord, err := ep.createRecord(event, ep.migrationTopic, ep.migrationSerializer)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create migration record")
+	}
+	return append(records, migrationRecord), nil
+}
 
-	key := make([]byte, 4)
-	binary.LittleEndian.PutUint32(key, tick)
+// decodeEvent adds the payload to the event. The raw event data is published anyway, if decoding fails.
+func (ep *EventProducer) decodeEvent(event *Event) {
+	if !ep.decode {
+		return
+	}
+	err := decodePayload(event)
+	if err != nil {
+		log.Printf("Error decoding payload of tick [%d] event [%d] type [%d]: %v", event.Tick, event.EventId, event.EventType, err)
+		event.Payload = nil
+		if ep.metrics != nil {
+			ep.metrics.IncPayloadDecodeErrors()
+		}
+	}
+}
+
+func (ep *EventProducer) createRecord(event *Event, topic string, serializer Serializer) (*kgo.Record, error) {
+	value, err := serializer.Serialize(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize event")
+	}
+
+	keyStrategy := ep.keyStrategy
+	if keyStrategy == nil {
+		keyStrategy = TickKeyStrategy{}
+	}
+	key, err := keyStrategy.Key(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create key")
+	}
 
-	record := &kgo.Record{Key: key, Value: payload}
+	record := &kgo.Record{
+		Topic: topic,
+		Key:   key,
+		Value: value,
+	}
+	contentType := serializer.ContentType()
+	if ep.cloudEvents != nil {
+		contentType, err = ep.cloudEvents.wrap(record, event, contentType, time.Now())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create cloud event")
+		}
+	}
+	record.Headers = append(ep.headers.create(event, contentType, serializer.SchemaVersion()), record.Headers...)
 	return record, nil
 }
+
+func decodePayload(event *Event) error {
+	data, err := decodeEventData(event)
+	if err != nil {
+		return err
+	}
+	event.Payload, err = payload.Decode(event.EventType, data)
+	return err
+}
@@ -2,20 +2,27 @@ package sync
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	eventspb "github.com/qubic/go-events/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"testing"
+	"time"
 )
 
 type FakeKafkaClient struct {
 	produceErr        error
 	processedMessages int
+	records           []*kgo.Record
 }
 
 func (fkc *FakeKafkaClient) Produce(_ context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
 	fkc.processedMessages++
+	fkc.records = append(fkc.records, r)
 	promise(r, fkc.produceErr)
 }
 
@@ -52,7 +59,7 @@ func TestEventPublisher_ProcessTickEvents(t *testing.T) {
 		},
 	}
 
-	count, err := pub.ProcessTickEvents(context.Background(), &tickEvents)
+	count, err := pub.ProcessTickEvents(context.Background(), 123, &tickEvents)
 	assert.NoError(t, err)
 	assert.Equal(t, 5, count)
 	assert.Equal(t, 5, kafkaClient.processedMessages)
@@ -92,7 +99,7 @@ func TestEventPublisher_ProcessTickEvents_GivenError_ThenReturn(t *testing.T) {
 		},
 	}
 
-	count, err := pub.ProcessTickEvents(context.Background(), &tickEvents)
+	count, err := pub.ProcessTickEvents(context.Background(), 123, &tickEvents)
 	assert.Error(t, err)
 	assert.Equal(t, 0, count)
 	assert.Equal(t, 2, kafkaClient.processedMessages) // abort after processing first tx
@@ -112,7 +119,148 @@ func TestEventPublisher_ProcessTickEvents_GivenNoEvent_ThenReturnZeroProcessed(t
 		TxEvents: nil,
 	}
 
-	count, err := pub.ProcessTickEvents(context.Background(), &tickEvents)
+	count, err := pub.ProcessTickEvents(context.Background(), 123, &tickEvents)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, count)
 }
+
+func TestEventPublisher_ProcessTickEvents_GivenTopicRoutes_ThenRouteByEventType(t *testing.T) {
+
+	kafkaClient := &FakeKafkaClient{}
+
+	pub := NewEventProducer(kafkaClient, WithTopicRouter(NewTopicRouter("default-topic", map[uint32]string{
+		0: "transfer-topic",
+	})))
+
+	tickEvents := eventspb.TickEvents{
+		Tick: 12345,
+		TxEvents: []*eventspb.TransactionEvents{
+			{
+				TxId: "tx-id-1",
+				Events: []*eventspb.Event{
+					{Header: &eventspb.Event_Header{EventId: 1}, EventType: 0},
+					{Header: &eventspb.Event_Header{EventId: 2}, EventType: 1},
+				},
+			},
+		},
+	}
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, &tickEvents)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "transfer-topic", kafkaClient.records[0].Topic)
+	assert.Equal(t, "default-topic", kafkaClient.records[1].Topic)
+}
+
+// StalledKafkaClient never calls the promise, like with network down.
+type StalledKafkaClient struct {
+	producedContexts []context.Context
+}
+
+func (skc *StalledKafkaClient) Produce(ctx context.Context, _ *kgo.Record, _ func(*kgo.Record, error)) {
+	skc.producedContexts = append(skc.producedContexts, ctx)
+}
+
+func TestEventPublisher_ProcessTickEvents_GivenStalledDelivery_ThenTimeout(t *testing.T) {
+
+	kafkaClient := &StalledKafkaClient{}
+	pub := NewEventProducer(kafkaClient, WithTickDeliveryTimeout(10*time.Millisecond))
+
+	_, err := pub.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	assert.ErrorIs(t, err, ErrDeliveryTimeout)
+	assert.Len(t, kafkaClient.producedContexts, 2)
+	for _, ctx := range kafkaClient.producedContexts {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+	}
+}
+
+func TestEventPublisher_ProcessTickEvents_GivenRecordTimeout_ThenDeliveryTimeoutError(t *testing.T) {
+
+	kafkaClient := &FakeKafkaClient{produceErr: kgo.ErrRecordTimeout}
+	pub := NewEventProducer(kafkaClient)
+
+	_, err := pub.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	assert.ErrorIs(t, err, ErrDeliveryTimeout)
+}
+
+// ConcurrentKafkaClient calls the promises concurrently like the kafka client.
+type ConcurrentKafkaClient struct{}
+
+func (ckc ConcurrentKafkaClient) Produce(_ context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	go promise(r, nil)
+}
+
+func TestEventPublisher_ProcessTickEvents_GivenConcurrentPromises_ThenCountAll(t *testing.T) {
+	tickEvents := &eventspb.TickEvents{Tick: 12345}
+	for i := range 10 {
+		transactionEvents := &eventspb.TransactionEvents{TxId: fmt.Sprintf("tx-id-%d", i)}
+		for j := range 100 {
+			transactionEvents.Events = append(transactionEvents.Events, &eventspb.Event{Header: &eventspb.Event_Header{EventId: uint64(i*100 + j)}})
+		}
+		tickEvents.TxEvents = append(tickEvents.TxEvents, transactionEvents)
+	}
+	pub := NewEventProducer(ConcurrentKafkaClient{})
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, tickEvents)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, count)
+}
+
+func TestEventPublisher_createEventRecord_GivenPayloadDecoding_ThenAddPayload(t *testing.T) {
+	pub := NewEventProducer(&FakeKafkaClient{}, WithPayloadDecoding(metrics))
+
+	record, err := pub.createEventRecord(testTransferEvent())
+	require.NoError(t, err)
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(record.Value, &event))
+	assert.Equal(t, "jXeSxIWWmtt45R7OZEdfBsCYwW27zUuCrIeQ/Y6ajDRKJ8b/lXtAmxLVMPI71cgnSdOdbDKXB6mJVUSbkG2ntgEAAAAAAAAA", event["eventData"])
+	assert.Equal(t, map[string]any{
+		"source":      "PJFKRWGTAAJVJGIYSBPJVEJQCVEAGNQBQKXTGPAKUDACMRKIYUNIRRNBDHSE",
+		"destination": "KOTZUMYVOBZFNEMDIBRRJWORBBEBDTQFPHULAVLQXEJCTSVWRYPGYXHFFOBC",
+		"amount":      float64(1),
+	}, event["payload"])
+}
+
+func TestEventPublisher_createEventRecord_GivenProtobufTopic_ThenDoNotDecode(t *testing.T) {
+	serializers, err := NewSerializers(context.Background(), map[string]string{"topic": FormatProtobuf}, SchemaVersion1, nil, false)
+	require.NoError(t, err)
+	pub := NewEventProducer(&FakeKafkaClient{},
+		WithTopicRouter(NewTopicRouter("topic", nil)),
+		WithSerializers(serializers),
+		WithPayloadDecoding(metrics),
+	)
+
+	event := testTransferEvent()
+	_, err = pub.createEventRecord(event)
+	require.NoError(t, err)
+	assert.Nil(t, event.Payload)
+}
+
+func TestEventPublisher_createEventRecord_GivenNoPayloadDecoding_ThenNoPayload(t *testing.T) {
+	pub := NewEventProducer(&FakeKafkaClient{})
+
+	record, err := pub.createEventRecord(testTransferEvent())
+	require.NoError(t, err)
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(record.Value, &event))
+	assert.NotContains(t, event, "payload")
+}
+
+func TestEventPublisher_createEventRecord_GivenInvalidEventData_ThenPublishWithoutPayload(t *testing.T) {
+	pub := NewEventProducer(&FakeKafkaClient{}, WithPayloadDecoding(metrics))
+
+	event := testTransferEvent()
+	event.EventData = "AAAA"
+	before := testutil.ToFloat64(metrics.payloadDecodeErrors)
+	record, err := pub.createEventRecord(event)
+	require.NoError(t, err)
+
+	var value map[string]any
+	require.NoError(t, json.Unmarshal(record.Value, &value))
+	assert.Equal(t, "AAAA", value["eventData"])
+	assert.NotContains(t, value, "payload")
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.payloadDecodeErrors))
+}
@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"slices"
+	"strconv"
+)
+
+const (
+	HeaderEpoch            = "epoch"
+	HeaderTick             = "tick"
+	HeaderEventId          = "eventId"
+	HeaderEventType        = "eventType"
+	HeaderTransactionHash  = "transactionHash"
+	HeaderSchemaVersion    = "schemaVersion"
+	HeaderContentType      = "contentType"
+	HeaderPublisherVersion = "publisherVersion"
+	HeaderSource           = "source"
+)
+
+const jsonContentType = "application/json"
+
+var AllHeaders = []string{
+	HeaderEpoch,
+	HeaderTick,
+	HeaderEventId,
+	HeaderEventType,
+	HeaderTransactionHash,
+	HeaderSchemaVersion,
+	HeaderContentType,
+	HeaderPublisherVersion,
+	HeaderSource,
+}
+
+// RecordHeaders creates the kafka record headers with event metadata. Consumers can route and filter records without
+// deserializing the payload.
+type RecordHeaders struct {
+	names            []string
+	publisherVersion string
+	source           string
+}
+
+// NewRecordHeaders creates record headers for the given header names. Empty names are ignored.
+func NewRecordHeaders(names []string, publisherVersion, source string) (*RecordHeaders, error) {
+	var headerNames []string
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(AllHeaders, name) {
+			return nil, errors.Errorf("unknown header [%s]", name)
+		}
+		headerNames = append(headerNames, name)
+	}
+	return &RecordHeaders{
+		names:            headerNames,
+		publisherVersion: publisherVersion,
+		source:           source,
+	}, nil
+}
+
+func (rh *RecordHeaders) create(event *Event, contentType string, schemaVersion int) []kgo.RecordHeader {
+	if rh == nil || len(rh.names) == 0 {
+		return nil
+	}
+	headers := make([]kgo.RecordHeader, 0, len(rh.names))
+	for _, name := range rh.names {
+		headers = append(headers, kgo.RecordHeader{Key: name, Value: []byte(rh.value(name, event, contentType, schemaVersion))})
+	}
+	return headers
+}
+
+// createAggregated creates the headers for records with multiple events (per tick or per transaction). Event specific
+// headers and, if there is none, the transaction hash are omitted.
+func (rh *RecordHeaders) createAggregated(event *Event, contentType string, schemaVersion int) []kgo.RecordHeader {
+	if rh == nil || len(rh.names) == 0 {
+		return nil
+	}
+	var headers []kgo.RecordHeader
+	for _, name := range rh.names {
+		if name == HeaderEventId || name == HeaderEventType || (name == HeaderTransactionHash && event.TransactionHash == "") {
+			continue
+		}
+		headers = append(headers, kgo.RecordHeader{Key: name, Value: []byte(rh.value(name, event, contentType, schemaVersion))})
+	}
+	return headers
+}
+
+func (rh *RecordHeaders) value(name string, event *Event, contentType string, schemaVersion int) string {
+	switch name {
+	case HeaderEpoch:
+		return strconv.FormatUint(uint64(event.Epoch), 10)
+	case HeaderTick:
+		return strconv.FormatUint(uint64(event.Tick), 10)
+	case HeaderEventId:
+		return strconv.FormatUint(event.EventId, 10)
+	case HeaderEventType:
+		return strconv.FormatUint(uint64(event.EventType), 10)
+	case HeaderTransactionHash:
+		return event.TransactionHash
+	case HeaderSchemaVersion:
+		return strconv.Itoa(schemaVersion)
+	case HeaderContentType:
+		return contentType
+	case HeaderPublisherVersion:
+		return rh.publisherVersion
+	case HeaderSource:
+		return rh.source
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"testing"
+)
+
+func TestRecordHeaders_create(t *testing.T) {
+	headers, err := NewRecordHeaders(AllHeaders, "v1.2.3", "localhost:8003")
+	require.NoError(t, err)
+
+	expected := []kgo.RecordHeader{
+		{Key: "epoch", Value: []byte("153")},
+		{Key: "tick", Value: []byte("21679416")},
+		{Key: "eventId", Value: []byte("13857")},
+		{Key: "eventType", Value: []byte("0")},
+		{Key: "transactionHash", Value: []byte("wjydyydyoltqlfdvnldtqqargoiamutsfqjnojyjhemhbrckrvxeyjodnfil")},
+		{Key: "schemaVersion", Value: []byte("1")},
+		{Key: "contentType", Value: []byte("application/json")},
+		{Key: "publisherVersion", Value: []byte("v1.2.3")},
+		{Key: "source", Value: []byte("localhost:8003")},
+	}
+	assert.Equal(t, expected, headers.create(testTransferEvent(), jsonContentType, SchemaVersion1))
+}
+
+func TestRecordHeaders_GivenSelection_ThenOnlyCreateSelected(t *testing.T) {
+	headers, err := NewRecordHeaders([]string{"tick", "", "eventType"}, "v1.2.3", "localhost:8003")
+	require.NoError(t, err)
+
+	expected := []kgo.RecordHeader{
+		{Key: "tick", Value: []byte("21679416")},
+		{Key: "eventType", Value: []byte("0")},
+	}
+	assert.Equal(t, expected, headers.create(testTransferEvent(), jsonContentType, SchemaVersion1))
+}
+
+func TestRecordHeaders_GivenNoHeaders_ThenNil(t *testing.T) {
+	headers, err := NewRecordHeaders([]string{""}, "v1.2.3", "localhost:8003")
+	require.NoError(t, err)
+	assert.Nil(t, headers.create(testTransferEvent(), jsonContentType, SchemaVersion1))
+
+	var noHeaders *RecordHeaders
+	assert.Nil(t, noHeaders.create(testTransferEvent(), jsonContentType, SchemaVersion1))
+}
+
+func TestNewRecordHeaders_GivenUnknownHeader_ThenError(t *testing.T) {
+	_, err := NewRecordHeaders([]string{"tick", "foo"}, "v1.2.3", "localhost:8003")
+	assert.Error(t, err)
+}
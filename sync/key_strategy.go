@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/qubic/go-events-publisher/identity"
+	"github.com/qubic/go-events-publisher/payload"
+	"log"
+)
+
+const (
+	KeyStrategyTick        = "tick"
+	KeyStrategyTransaction = "transaction"
+	KeyStrategyEvent       = "event"
+	KeyStrategySource      = "source"
+	KeyStrategyDestination = "destination"
+)
+
+// KeyStrategy creates the kafka record key for an event. The key determines the partition of the record.
+type KeyStrategy interface {
+	Key(event *Event) ([]byte, error)
+}
+
+func NewKeyStrategy(name string) (KeyStrategy, error) {
+	switch name {
+	case KeyStrategyTick:
+		return TickKeyStrategy{}, nil
+	case KeyStrategyTransaction:
+		return TransactionKeyStrategy{}, nil
+	case KeyStrategyEvent:
+		return EventIdKeyStrategy{}, nil
+	case KeyStrategySource:
+		return IdentityKeyStrategy{destination: false}, nil
+	case KeyStrategyDestination:
+		return IdentityKeyStrategy{destination: true}, nil
+	default:
+		return nil, errors.Errorf("unknown key strategy [%s]", name)
+	}
+}
+
+// TickKeyStrategy keys records with the 4 byte little endian tick number. All events of a tick land in one partition.
+type TickKeyStrategy struct{}
+
+func (TickKeyStrategy) Key(event *Event) ([]byte, error) {
+	key := make([]byte, 4)
+	binary.LittleEndian.PutUint32(key, event.Tick)
+	return key, nil
+}
+
+// TransactionKeyStrategy keys records with the transaction hash. Events without transaction are keyed by tick.
+type TransactionKeyStrategy struct{}
+
+func (TransactionKeyStrategy) Key(event *Event) ([]byte, error) {
+	if event.TransactionHash == "" {
+		return TickKeyStrategy{}.Key(event)
+	}
+	return []byte(event.TransactionHash), nil
+}
+
+// EventIdKeyStrategy keys records with the little endian epoch (4 bytes) followed by the little endian event id
+// (8 bytes). Event ids are unique per epoch only.
+type EventIdKeyStrategy struct{}
+
+func (EventIdKeyStrategy) Key(event *Event) ([]byte, error) {
+	key := make([]byte, 12)
+	binary.LittleEndian.PutUint32(key, event.Epoch)
+	binary.LittleEndian.PutUint64(key[4:], event.EventId)
+	return key, nil
+}
+
+// IdentityKeyStrategy keys qu transfer events with the source or destination identity. All other events and qu transfer
+// events with invalid event data are keyed by tick.
+type IdentityKeyStrategy struct {
+	destination bool
+}
+
+func (s IdentityKeyStrategy) Key(event *Event) ([]byte, error) {
+	if event.EventType != payload.TypeQuTransfer {
+		return TickKeyStrategy{}.Key(event)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(event.EventData)
+	if err == nil && len(data) < 64 {
+		err = errors.Errorf("invalid qu transfer event data length [%d]", len(data))
+	}
+	if err != nil {
+		log.Printf("Error creating identity key for tick [%d] event [%d], using tick key: %v", event.Tick, event.EventId, err)
+		return TickKeyStrategy{}.Key(event)
+	}
+
+	// qu transfer: source public key (32 bytes), destination public key (32 bytes), amount (8 bytes)
+	publicKey := data[0:32]
+	if s.destination {
+		publicKey = data[32:64]
+	}
+	return []byte(identity.FromPublicKey([identity.PublicKeyLength]byte(publicKey))), nil
+}
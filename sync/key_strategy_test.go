@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func testTransferEvent() *Event {
+	return &Event{
+		Epoch:           153,
+		Tick:            21679416,
+		EventId:         13857,
+		EventDigest:     1715952909454684526,
+		TransactionHash: "wjydyydyoltqlfdvnldtqqargoiamutsfqjnojyjhemhbrckrvxeyjodnfil",
+		EventType:       0,
+		EventSize:       72,
+		EventData:       "jXeSxIWWmtt45R7OZEdfBsCYwW27zUuCrIeQ/Y6ajDRKJ8b/lXtAmxLVMPI71cgnSdOdbDKXB6mJVUSbkG2ntgEAAAAAAAAA",
+	}
+}
+
+func TestKeyStrategy_Key(t *testing.T) {
+	event := testTransferEvent()
+
+	tests := []struct {
+		strategy string
+		expected []byte
+	}{
+		{KeyStrategyTick, []byte{0x38, 0xcd, 0x4a, 0x01}},
+		{KeyStrategyTransaction, []byte(event.TransactionHash)},
+		{KeyStrategyEvent, []byte{153, 0, 0, 0, 0x21, 0x36, 0, 0, 0, 0, 0, 0}},
+		{KeyStrategySource, []byte("PJFKRWGTAAJVJGIYSBPJVEJQCVEAGNQBQKXTGPAKUDACMRKIYUNIRRNBDHSE")},
+		{KeyStrategyDestination, []byte("KOTZUMYVOBZFNEMDIBRRJWORBBEBDTQFPHULAVLQXEJCTSVWRYPGYXHFFOBC")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			keyStrategy, err := NewKeyStrategy(tt.strategy)
+			require.NoError(t, err)
+			key, err := keyStrategy.Key(event)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, key)
+		})
+	}
+}
+
+func TestKeyStrategy_GivenNoTransaction_ThenKeyByTick(t *testing.T) {
+	event := testTransferEvent()
+	event.TransactionHash = ""
+
+	key, err := TransactionKeyStrategy{}.Key(event)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x38, 0xcd, 0x4a, 0x01}, key)
+}
+
+func TestKeyStrategy_GivenNoTransferEvent_ThenKeyByTick(t *testing.T) {
+	event := testTransferEvent()
+	event.EventType = 1
+
+	key, err := IdentityKeyStrategy{}.Key(event)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x38, 0xcd, 0x4a, 0x01}, key)
+}
+
+func TestKeyStrategy_GivenInvalidTransferEvent_ThenTickKey(t *testing.T) {
+	for _, eventData := range []string{"AAAA", "invalid"} {
+		event := testTransferEvent()
+		event.EventData = eventData
+
+		key, err := IdentityKeyStrategy{}.Key(event)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x38, 0xcd, 0x4a, 0x01}, key) // tick 21679416
+	}
+}
+
+func TestNewKeyStrategy_GivenUnknown_ThenError(t *testing.T) {
+	_, err := NewKeyStrategy("foo")
+	assert.Error(t, err)
+}
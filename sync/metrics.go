@@ -13,6 +13,11 @@ type Metrics struct {
 	processingEpochGauge  prometheus.Gauge
 	processedMessageCount prometheus.Counter
 	processedTicksCount   prometheus.Counter
+	deadLetterCount       prometheus.Counter
+	deliveryTimeoutCount  prometheus.Counter
+	digestMismatchCount   prometheus.Counter
+	payloadDecodeErrors   prometheus.Counter
+	prefetchQueueGauge    prometheus.Gauge
 }
 
 func NewMetrics(namespace string) *Metrics {
@@ -34,6 +39,26 @@ func NewMetrics(namespace string) *Metrics {
 			Name: fmt.Sprintf("%s_processed_message_count", namespace),
 			Help: "The total number of processed message records",
 		}),
+		deadLetterCount: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_dead_letter_count", namespace),
+			Help: "The total number of events sent to the dead letter topic",
+		}),
+		deliveryTimeoutCount: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_delivery_timeout_count", namespace),
+			Help: "The total number of ticks that could not be delivered in time",
+		}),
+		digestMismatchCount: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_digest_mismatch_count", namespace),
+			Help: "The total number of events with a digest that does not match the event data",
+		}),
+		payloadDecodeErrors: promauto.NewCounter(prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_payload_decode_error_count", namespace),
+			Help: "The total number of events that were published without payload, because the event data could not be decoded",
+		}),
+		prefetchQueueGauge: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: fmt.Sprintf("%s_prefetch_queue_depth", namespace),
+			Help: "The number of ticks that are fetched ahead and wait for publishing",
+		}),
 		// metrics for comparison to event source
 		sourceTickGauge: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: fmt.Sprintf("%s_source_tick", namespace),
@@ -60,7 +85,27 @@ func (metrics *Metrics) AddProcessedMessages(count int) {
 	metrics.processedMessageCount.Add(float64(count))
 }
 
+func (metrics *Metrics) AddDeadLetters(count int) {
+	metrics.deadLetterCount.Add(float64(count))
+}
+
+func (metrics *Metrics) IncDeliveryTimeouts() {
+	metrics.deliveryTimeoutCount.Inc()
+}
+
 func (metrics *Metrics) SetSourceTick(epoch uint32, tick uint32) {
 	metrics.sourceEpochGauge.Set(float64(epoch))
 	metrics.sourceTickGauge.Set(float64(tick))
 }
+
+func (metrics *Metrics) IncDigestMismatches() {
+	metrics.digestMismatchCount.Inc()
+}
+
+func (metrics *Metrics) IncPayloadDecodeErrors() {
+	metrics.payloadDecodeErrors.Inc()
+}
+
+func (metrics *Metrics) SetPrefetchQueueDepth(depth int) {
+	metrics.prefetchQueueGauge.Set(float64(depth))
+}
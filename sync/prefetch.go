@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"context"
+	eventspb "github.com/qubic/go-events/proto"
+	"time"
+)
+
+// fetchedTick is the result of fetching the events of one tick.
+type fetchedTick struct {
+	tick       uint32
+	tickEvents *eventspb.TickEvents
+	err        error
+	start      time.Time
+	read       time.Duration
+}
+
+// tickPrefetcher fetches the events of the following ticks concurrently, while the current tick is published. The
+// results are returned in tick order. At most concurrency ticks are in flight at the same time, including the tick that
+// is published. So concurrency 1 fetches one tick after the other and concurrency n fetches up to n-1 ticks ahead.
+type tickPrefetcher struct {
+	client  Client
+	metrics *Metrics
+	slots   chan struct{}
+	queue   chan chan fetchedTick
+}
+
+// startTickPrefetcher starts fetching the ticks in the range (to exclusive). Fetching stops, if the context is done.
+func startTickPrefetcher(ctx context.Context, client Client, metrics *Metrics, concurrency int, from, toExcl uint32) *tickPrefetcher {
+	concurrency = max(concurrency, 1)
+	tp := &tickPrefetcher{
+		client:  client,
+		metrics: metrics,
+		slots:   make(chan struct{}, concurrency),
+		queue:   make(chan chan fetchedTick, concurrency),
+	}
+	go tp.fetchAll(ctx, from, toExcl)
+	return tp
+}
+
+func (tp *tickPrefetcher) fetchAll(ctx context.Context, from, toExcl uint32) {
+	defer close(tp.queue)
+	for tick := from; tick < toExcl; tick++ {
+		select {
+		case tp.slots <- struct{}{}: // released after publishing
+		case <-ctx.Done():
+			return
+		}
+		result := make(chan fetchedTick, 1)
+		go func() {
+			start := time.Now()
+			tickEvents, err := tp.client.GetEvents(ctx, tick)
+			result <- fetchedTick{tick: tick, tickEvents: tickEvents, err: err, start: start, read: time.Since(start)}
+		}()
+		tp.queue <- result // never blocks, queue has one place per slot
+		tp.setQueueDepth()
+	}
+}
+
+// next returns the next tick in order. Returns false, if all ticks are returned or the context is done. The caller
+// needs to call release after processing the tick.
+func (tp *tickPrefetcher) next(ctx context.Context) (fetchedTick, bool) {
+	var result chan fetchedTick
+	select {
+	case r, ok := <-tp.queue:
+		if !ok {
+			return fetchedTick{}, false
+		}
+		result = r
+	case <-ctx.Done():
+		return fetchedTick{}, false
+	}
+	tp.setQueueDepth()
+
+	select {
+	case fetched := <-result:
+		return fetched, true
+	case <-ctx.Done():
+		return fetchedTick{}, false
+	}
+}
+
+// release frees the slot of a processed tick, so that the next tick can be fetched.
+func (tp *tickPrefetcher) release() {
+	<-tp.slots
+}
+
+func (tp *tickPrefetcher) setQueueDepth() {
+	if tp.metrics != nil {
+		tp.metrics.SetPrefetchQueueDepth(len(tp.queue))
+	}
+}
@@ -0,0 +1,122 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"github.com/qubic/go-events-publisher/client"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// SlowEventClient returns the events after a delay, that is shorter for later ticks, so that fetches complete out of
+// order. It records the number of requests and the maximum number of concurrent requests.
+type SlowEventClient struct {
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+	calls       int
+	failingTick uint32
+}
+
+func (c *SlowEventClient) GetEvents(_ context.Context, tickNumber uint32) (*eventspb.TickEvents, error) {
+	c.mutex.Lock()
+	c.inFlight++
+	c.calls++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.mutex.Unlock()
+
+	time.Sleep(time.Duration(10-tickNumber%10) * time.Millisecond)
+
+	c.mutex.Lock()
+	c.inFlight--
+	c.mutex.Unlock()
+	if tickNumber == c.failingTick {
+		return nil, errors.New("test error")
+	}
+	return &eventspb.TickEvents{Tick: tickNumber}, nil
+}
+
+func (c *SlowEventClient) GetStatus(_ context.Context) (*client.EventStatus, error) {
+	return nil, errors.New("not implemented")
+}
+
+// RecordingEventProcessor records the published ticks.
+type RecordingEventProcessor struct {
+	ticks []uint32
+}
+
+func (p *RecordingEventProcessor) ProcessTickEvents(_ context.Context, _ uint32, tickEvents *eventspb.TickEvents) (int, error) {
+	p.ticks = append(p.ticks, tickEvents.GetTick())
+	return 0, nil
+}
+
+func TestTickPrefetcher_next_ThenReturnTicksInOrder(t *testing.T) {
+	eventClient := &SlowEventClient{}
+	prefetcher := startTickPrefetcher(context.Background(), eventClient, metrics, 4, 100, 120)
+
+	var ticks []uint32
+	for {
+		fetched, ok := prefetcher.next(context.Background())
+		if !ok {
+			break
+		}
+		require.NoError(t, fetched.err)
+		ticks = append(ticks, fetched.tickEvents.GetTick())
+		prefetcher.release()
+	}
+
+	require.Len(t, ticks, 20)
+	for i, tick := range ticks {
+		assert.Equal(t, uint32(100+i), tick)
+	}
+	assert.Equal(t, 4, eventClient.maxInFlight)
+}
+
+func TestTickPrefetcher_next_GivenUnreleasedTick_ThenFetchConcurrencyMinusOneAhead(t *testing.T) {
+	for _, concurrency := range []int{1, 2, 4} {
+		eventClient := &SlowEventClient{}
+		prefetcher := startTickPrefetcher(context.Background(), eventClient, metrics, concurrency, 100, 120)
+
+		fetched, ok := prefetcher.next(context.Background())
+		require.True(t, ok)
+		assert.Equal(t, uint32(100), fetched.tickEvents.GetTick())
+		time.Sleep(50 * time.Millisecond) // let the prefetcher fetch ahead
+
+		eventClient.mutex.Lock()
+		assert.Equal(t, concurrency, eventClient.calls, "concurrency [%d]", concurrency)
+		eventClient.mutex.Unlock()
+	}
+}
+
+func TestTickPrefetcher_next_GivenCancelledContext_ThenStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	prefetcher := startTickPrefetcher(ctx, &SlowEventClient{}, metrics, 2, 100, 120)
+	cancel()
+
+	_, ok := prefetcher.next(ctx)
+	assert.False(t, ok)
+}
+
+func TestEventProcessor_processTickEventsRange_GivenPrefetch_ThenPublishAndCheckpointInOrder(t *testing.T) {
+	producer := &RecordingEventProcessor{}
+	reader := NewEventProcessor(&SlowEventClient{failingTick: 115}, producer, store, metrics, WithPrefetch(4))
+
+	err := reader.processTickEventsRange(context.Background(), 99, 100, 120)
+	assert.ErrorContains(t, err, "processing tick [115]")
+
+	require.Len(t, producer.ticks, 15)
+	for i, tick := range producer.ticks {
+		assert.Equal(t, uint32(100+i), tick)
+	}
+	lastProcessedTick, err := store.GetLastProcessedTick(99)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(114), lastProcessedTick)
+
+	// clean up
+	err = store.deleteLastProcessedTicks(99, 100)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"log"
+)
+
+const HeaderReplay = "replay"
+
+var replayHeader = kgo.RecordHeader{Key: HeaderReplay, Value: []byte("true")}
+
+// replayKafkaClient adds the replay header to all records.
+type replayKafkaClient struct {
+	KafkaClient
+}
+
+func (rc replayKafkaClient) Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	r.Headers = append(r.Headers, replayHeader)
+	rc.KafkaClient.Produce(ctx, r, promise)
+}
+
+// WithReplayHeader adds the `replay: true` header to all records. Used for publishing ticks again.
+func WithReplayHeader() ProducerOption {
+	return func(ep *EventProducer) {
+		ep.kcl = replayKafkaClient{ep.kcl}
+	}
+}
+
+// Replayer publishes the events of a tick range again. The checkpoints of the live sync are not changed, so that a
+// replay can run alongside the live sync.
+type Replayer struct {
+	eventClient    Client
+	eventPublisher Producer
+	concurrency    int
+}
+
+func NewReplayer(client Client, publisher Producer, concurrency int) *Replayer {
+	return &Replayer{
+		eventClient:    client,
+		eventPublisher: publisher,
+		concurrency:    concurrency,
+	}
+}
+
+// Replay publishes the events of the ticks from fromTick to toTick (inclusive) of the epoch. Returns the number of
+// published events.
+func (r *Replayer) Replay(ctx context.Context, epoch, fromTick, toTick uint32) (int, error) {
+	if fromTick == 0 || fromTick > toTick {
+		return 0, errors.Errorf("invalid tick range [%d] to [%d]", fromTick, toTick)
+	}
+	log.Printf("Replaying ticks from [%d] to [%d] of epoch [%d].", fromTick, toTick, epoch)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops prefetching on error
+
+	var total int
+	prefetcher := startTickPrefetcher(ctx, r.eventClient, nil, r.concurrency, fromTick, toTick+1)
+	for tick := fromTick; tick <= toTick; tick++ {
+		fetched, ok := prefetcher.next(ctx)
+		if !ok {
+			return total, errors.Wrapf(ctx.Err(), "fetching tick [%d]", tick)
+		}
+		if fetched.err != nil {
+			return total, errors.Wrapf(fetched.err, "getting events of tick [%d]", tick)
+		}
+		count, err := r.eventPublisher.ProcessTickEvents(ctx, epoch, fetched.tickEvents)
+		prefetcher.release()
+		if err != nil {
+			return total, errors.Wrapf(err, "replaying tick [%d]", tick)
+		}
+		total += count
+		log.Printf("Replayed [%d] events of tick [%d].", count, tick)
+	}
+	return total, nil
+}
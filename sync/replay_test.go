@@ -0,0 +1,50 @@
+package sync
+
+import (
+	"context"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReplayer_Replay(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	producer := NewEventProducer(kafkaClient, WithTopicRouter(NewTopicRouter("replay-topic", nil)), WithReplayHeader())
+	eventClient := &FakeEventClient{events: map[uint32]*eventspb.TickEvents{
+		12345: testTickEvents(),
+		12346: {Tick: 12346},
+		12347: {Tick: 12347, TxEvents: []*eventspb.TransactionEvents{{TxId: "tx-id-2", Events: []*eventspb.Event{{Header: &eventspb.Event_Header{EventId: 3}}}}}},
+	}}
+
+	count, err := NewReplayer(eventClient, producer, 2).Replay(context.Background(), 123, 12345, 12347)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.Len(t, kafkaClient.records, 3)
+	for _, record := range kafkaClient.records {
+		assert.Equal(t, "replay-topic", record.Topic)
+		assert.Equal(t, "true", headerValue(record, HeaderReplay))
+	}
+
+	// checkpoints are not changed
+	_, err = store.GetLastProcessedTick(123)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReplayer_Replay_GivenInvalidRange_ThenError(t *testing.T) {
+	replayer := NewReplayer(&FakeEventClient{}, &FakeEventProcessor{}, 1)
+
+	_, err := replayer.Replay(context.Background(), 123, 12346, 12345)
+	assert.Error(t, err)
+
+	_, err = replayer.Replay(context.Background(), 123, 0, 12345)
+	assert.Error(t, err)
+}
+
+func TestReplayer_Replay_GivenProcessingError_ThenError(t *testing.T) {
+	eventClient := &FakeEventClient{events: map[uint32]*eventspb.TickEvents{}}
+	replayer := NewReplayer(eventClient, &FailingEventProcessor{err: ErrDeliveryTimeout}, 1)
+
+	_, err := replayer.Replay(context.Background(), 123, 12345, 12346)
+	assert.ErrorIs(t, err, ErrDeliveryTimeout)
+}
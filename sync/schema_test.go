@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/hamba/avro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestSerializers_Golden fails, if the published shape of a schema version changes. Changes need a new schema version
+// and new golden files (go test ./sync -run Golden -update).
+func TestSerializers_Golden(t *testing.T) {
+	type goldenTest struct {
+		name       string
+		serializer Serializer
+	}
+	var tests []goldenTest
+	for _, version := range JsonSchemaVersions {
+		serializer, err := NewJsonSerializer(version)
+		require.NoError(t, err)
+		tests = append(tests, goldenTest{name: fmt.Sprintf("json_v%d.json", version), serializer: serializer})
+	}
+	tests = append(tests,
+		goldenTest{name: "protobuf_v1.bin", serializer: ProtobufSerializer{}},
+		goldenTest{name: "avro_v1.bin", serializer: &AvroSerializer{schema: avro.MustParse(EventAvroSchema), schemaId: 1}},
+	)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testTransferEvent()
+			require.NoError(t, decodePayload(event))
+			actual, err := tt.serializer.Serialize(event)
+			require.NoError(t, err)
+			assertGolden(t, tt.name, actual)
+		})
+	}
+}
+
+func TestTickRecord_Golden(t *testing.T) {
+	ticks, err := NewTickAggregation("topic", nil, DefaultTickRecordMaxBytes)
+	require.NoError(t, err)
+	pub := NewEventProducer(&FakeKafkaClient{}, WithTickAggregation(ticks))
+	event := testTransferEvent()
+	require.NoError(t, decodePayload(event))
+
+	records, err := pub.createTickRecords(event.Epoch, event.Tick, []tickEvent{{event: event}})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assertGolden(t, "tick_v1.json", records[0].Value)
+}
+
+func TestTransactionMessage_Golden(t *testing.T) {
+	pub := NewEventProducer(&FakeKafkaClient{}, WithTransactionTopic("topic"))
+	event := testTransferEvent()
+	require.NoError(t, decodePayload(event))
+
+	record, err := pub.createTransactionRecord(event.Epoch, event.Tick, event.TransactionHash, []*Event{event}, false)
+	require.NoError(t, err)
+	assertGolden(t, "transaction_v1.json", record.Value)
+}
+
+func assertGolden(t *testing.T, name string, actual []byte) {
+	golden := filepath.Join("testdata", "golden", name)
+	if *update {
+		require.NoError(t, os.WriteFile(golden, actual, 0644))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err, "missing golden file for schema version")
+	assert.Equal(t, string(expected), string(actual), "published shape changed without schema version bump")
+}
+
+func TestJsonSerializer_SchemaVersion(t *testing.T) {
+	for _, version := range JsonSchemaVersions {
+		serializer, err := NewJsonSerializer(version)
+		require.NoError(t, err)
+		assert.Equal(t, version, serializer.SchemaVersion())
+	}
+	assert.Equal(t, SchemaVersion1, JsonSerializer{}.SchemaVersion())
+
+	_, err := NewJsonSerializer(3)
+	assert.Error(t, err)
+}
+
+func TestNewSerializer_GivenUnsupportedVersion_ThenError(t *testing.T) {
+	_, err := NewSerializer(FormatProtobuf, SchemaVersion2)
+	assert.Error(t, err)
+}
+
+func TestEventProducer_ProcessTickEvents_GivenMigrationTopic_ThenPublishBothVersions(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	headers, err := NewRecordHeaders([]string{HeaderSchemaVersion}, "", "")
+	require.NoError(t, err)
+	migrationSerializer, err := NewJsonSerializer(SchemaVersion2)
+	require.NoError(t, err)
+	pub := NewEventProducer(kafkaClient,
+		WithTopicRouter(NewTopicRouter("topic", nil)),
+		WithRecordHeaders(headers),
+		WithMigrationTopic("migration-topic", migrationSerializer),
+	)
+
+	count, err := pub.ProcessTickEvents(context.Background(), 153, digestTestTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count) // migration records are not counted
+	require.Len(t, kafkaClient.records, 4)
+
+	assert.Equal(t, "topic", kafkaClient.records[0].Topic)
+	assert.Equal(t, "1", headerValue(kafkaClient.records[0], HeaderSchemaVersion))
+	assert.NotContains(t, string(kafkaClient.records[0].Value), "schemaVersion")
+
+	assert.Equal(t, "migration-topic", kafkaClient.records[1].Topic)
+	assert.Equal(t, "2", headerValue(kafkaClient.records[1], HeaderSchemaVersion))
+	assert.Contains(t, string(kafkaClient.records[1].Value), `"schemaVersion":2`)
+	assert.Equal(t, kafkaClient.records[0].Key, kafkaClient.records[1].Key)
+}
@@ -0,0 +1,208 @@
+package sync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	publisherpb "github.com/qubic/go-events-publisher/proto"
+	"google.golang.org/protobuf/proto"
+	"slices"
+)
+
+const (
+	FormatJson     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+const protobufContentType = "application/x-protobuf"
+
+const (
+	SchemaVersion1 = 1 // event fields
+	SchemaVersion2 = 2 // event fields with explicit schema version field
+)
+
+// JsonSchemaVersions are the supported json schema versions. Every version needs golden files (see testdata/golden).
+var JsonSchemaVersions = []int{SchemaVersion1, SchemaVersion2}
+
+// Serializer creates the record value for an event.
+type Serializer interface {
+	Serialize(event *Event) ([]byte, error)
+	ContentType() string
+	SchemaVersion() int
+}
+
+// NewSerializer creates the serializer for the format and schema version. Only json supports multiple versions.
+func NewSerializer(format string, version int) (Serializer, error) {
+	switch format {
+	case FormatJson:
+		return NewJsonSerializer(version)
+	case FormatProtobuf:
+		if version != SchemaVersion1 {
+			return nil, errors.Errorf("format [%s] does not support schema version [%d]", format, version)
+		}
+		return ProtobufSerializer{}, nil
+	default:
+		return nil, errors.Errorf("unknown format [%s]", format)
+	}
+}
+
+// Serializers selects the serializer per topic. Topics without explicit format are serialized as json.
+type Serializers struct {
+	topics map[string]Serializer
+	json   JsonSerializer
+}
+
+// NewSerializers creates the serializers for the given topic to format mapping and schema version. Avro topics need a
+// schema registry. Their schema is registered under the `<topic>-value` subject.
+func NewSerializers(ctx context.Context, topicFormats map[string]string, version int, registry SchemaRegistry, autoRegister bool) (*Serializers, error) {
+	jsonSerializer, err := NewJsonSerializer(version)
+	if err != nil {
+		return nil, err
+	}
+	topics := make(map[string]Serializer, len(topicFormats))
+	for topic, format := range topicFormats {
+		var serializer Serializer
+		var err error
+		if format == FormatAvro {
+			if registry == nil {
+				return nil, errors.Errorf("format [%s] of topic [%s] needs a schema registry", format, topic)
+			}
+			if version != SchemaVersion1 {
+				return nil, errors.Errorf("format [%s] of topic [%s] does not support schema version [%d]", format, topic, version)
+			}
+			serializer, err = NewAvroSerializer(ctx, registry, topic+"-value", autoRegister)
+		} else {
+			serializer, err = NewSerializer(format, version)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating serializer for topic [%s]", topic)
+		}
+		topics[topic] = serializer
+	}
+	return &Serializers{topics: topics, json: jsonSerializer}, nil
+}
+
+func (s *Serializers) get(topic string) Serializer {
+	if s == nil {
+		return JsonSerializer{}
+	}
+	if serializer, ok := s.topics[topic]; ok {
+		return serializer
+	}
+	return s.json
+}
+
+// JsonSerializer serializes the event as json. The event data is base64 encoded. The zero value serializes schema
+// version 1.
+type JsonSerializer struct {
+	version int
+}
+
+func NewJsonSerializer(version int) (JsonSerializer, error) {
+	if !slices.Contains(JsonSchemaVersions, version) {
+		return JsonSerializer{}, errors.Errorf("unknown schema version [%d]", version)
+	}
+	return JsonSerializer{version: version}, nil
+}
+
+// eventV1 is the json message of schema version 1. Published schemas are frozen, changes need a new version.
+type eventV1 struct {
+	Epoch           uint32 `json:"epoch"`
+	Tick            uint32 `json:"tick"`
+	EventId         uint64 `json:"eventId"`
+	EventDigest     uint64 `json:"eventDigest"`
+	TransactionHash string `json:"transactionHash"`
+	EventType       uint32 `json:"eventType"`
+	EventSize       uint32 `json:"eventSize"`
+	EventData       string `json:"eventData"`
+	Payload         any    `json:"payload,omitempty"`
+}
+
+// eventV2 is the json message of schema version 2. Published schemas are frozen, changes need a new version.
+type eventV2 struct {
+	SchemaVersion   int    `json:"schemaVersion"`
+	Epoch           uint32 `json:"epoch"`
+	Tick            uint32 `json:"tick"`
+	EventId         uint64 `json:"eventId"`
+	EventDigest     uint64 `json:"eventDigest"`
+	TransactionHash string `json:"transactionHash"`
+	EventType       uint32 `json:"eventType"`
+	EventSize       uint32 `json:"eventSize"`
+	EventData       string `json:"eventData"`
+	Payload         any    `json:"payload,omitempty"`
+}
+
+func (s JsonSerializer) Serialize(event *Event) ([]byte, error) {
+	if s.version == SchemaVersion2 {
+		return json.Marshal(eventV2{
+			SchemaVersion:   SchemaVersion2,
+			Epoch:           event.Epoch,
+			Tick:            event.Tick,
+			EventId:         event.EventId,
+			EventDigest:     event.EventDigest,
+			TransactionHash: event.TransactionHash,
+			EventType:       event.EventType,
+			EventSize:       event.EventSize,
+			EventData:       event.EventData,
+			Payload:         event.Payload,
+		})
+	}
+	return json.Marshal(eventV1{
+		Epoch:           event.Epoch,
+		Tick:            event.Tick,
+		EventId:         event.EventId,
+		EventDigest:     event.EventDigest,
+		TransactionHash: event.TransactionHash,
+		EventType:       event.EventType,
+		EventSize:       event.EventSize,
+		EventData:       event.EventData,
+		Payload:         event.Payload,
+	})
+}
+
+func (JsonSerializer) ContentType() string {
+	return jsonContentType
+}
+
+func (s JsonSerializer) SchemaVersion() int {
+	return max(s.version, SchemaVersion1)
+}
+
+// ProtobufSerializer serializes the event as protobuf envelope (see proto/events.proto). The event data is raw bytes.
+type ProtobufSerializer struct{}
+
+func (ProtobufSerializer) Serialize(event *Event) ([]byte, error) {
+	eventData, err := decodeEventData(event)
+	if err != nil {
+		return nil, err
+	}
+	message := &publisherpb.Event{
+		Epoch:           event.Epoch,
+		Tick:            event.Tick,
+		EventId:         event.EventId,
+		EventDigest:     event.EventDigest,
+		TransactionHash: event.TransactionHash,
+		EventType:       event.EventType,
+		EventSize:       event.EventSize,
+		EventData:       eventData,
+	}
+	return proto.Marshal(message)
+}
+
+func (ProtobufSerializer) ContentType() string {
+	return protobufContentType
+}
+
+func (ProtobufSerializer) SchemaVersion() int {
+	return SchemaVersion1
+}
+
+func decodeEventData(event *Event) ([]byte, error) {
+	eventData, err := base64.StdEncoding.DecodeString(event.EventData)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding event data")
+	}
+	return eventData, nil
+}
@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	publisherpb "github.com/qubic/go-events-publisher/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestJsonSerializer_Serialize(t *testing.T) {
+	event := testTransferEvent()
+	payload, err := JsonSerializer{}.Serialize(event)
+	require.NoError(t, err)
+
+	var result Event
+	require.NoError(t, json.Unmarshal(payload, &result))
+	assert.Equal(t, *event, result)
+}
+
+func TestProtobufSerializer_Serialize(t *testing.T) {
+	event := testTransferEvent()
+	payload, err := ProtobufSerializer{}.Serialize(event)
+	require.NoError(t, err)
+
+	var result publisherpb.Event
+	require.NoError(t, proto.Unmarshal(payload, &result))
+	data, err := base64.StdEncoding.DecodeString(event.EventData)
+	require.NoError(t, err)
+	assert.Equal(t, event.Epoch, result.Epoch)
+	assert.Equal(t, event.Tick, result.Tick)
+	assert.Equal(t, event.EventId, result.EventId)
+	assert.Equal(t, event.EventDigest, result.EventDigest)
+	assert.Equal(t, event.TransactionHash, result.TransactionHash)
+	assert.Equal(t, event.EventType, result.EventType)
+	assert.Equal(t, event.EventSize, result.EventSize)
+	assert.Equal(t, data, result.EventData)
+}
+
+func TestProtobufSerializer_GivenInvalidEventData_ThenError(t *testing.T) {
+	event := testTransferEvent()
+	event.EventData = "not base64!"
+	_, err := ProtobufSerializer{}.Serialize(event)
+	assert.Error(t, err)
+}
+
+func TestNewSerializers(t *testing.T) {
+	serializers, err := NewSerializers(context.Background(), map[string]string{"proto-topic": "protobuf", "json-topic": "json"}, SchemaVersion1, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, ProtobufSerializer{}, serializers.get("proto-topic"))
+	assert.Equal(t, JsonSerializer{version: SchemaVersion1}, serializers.get("json-topic"))
+	assert.Equal(t, JsonSerializer{version: SchemaVersion1}, serializers.get("other-topic"))
+
+	var noSerializers *Serializers
+	assert.Equal(t, JsonSerializer{}, noSerializers.get("other-topic"))
+}
+
+func TestNewSerializers_GivenUnknownFormat_ThenError(t *testing.T) {
+	_, err := NewSerializers(context.Background(), map[string]string{"topic": "xml"}, SchemaVersion1, nil, false)
+	assert.Error(t, err)
+}
+
+func TestEventProducer_GivenProtobufTopic_ThenSerializeAsProtobuf(t *testing.T) {
+	headers, err := NewRecordHeaders([]string{HeaderContentType}, "", "")
+	require.NoError(t, err)
+	serializers, err := NewSerializers(context.Background(), map[string]string{"proto-topic": FormatProtobuf}, SchemaVersion1, nil, false)
+	require.NoError(t, err)
+	kafkaClient := &FakeKafkaClient{}
+	producer := NewEventProducer(kafkaClient,
+		WithTopicRouter(NewTopicRouter("json-topic", map[uint32]string{1: "proto-topic"})),
+		WithRecordHeaders(headers),
+		WithSerializers(serializers),
+	)
+
+	tickEvents := testTickEvents()
+	tickEvents.TxEvents[0].Events[1].EventType = 1
+	_, err = producer.ProcessTickEvents(context.Background(), 123, tickEvents)
+	require.NoError(t, err)
+
+	require.Len(t, kafkaClient.records, 2)
+	assert.Equal(t, "json-topic", kafkaClient.records[0].Topic)
+	assert.Equal(t, "application/json", headerValue(kafkaClient.records[0], HeaderContentType))
+	assert.True(t, json.Valid(kafkaClient.records[0].Value))
+
+	assert.Equal(t, "proto-topic", kafkaClient.records[1].Topic)
+	assert.Equal(t, "application/x-protobuf", headerValue(kafkaClient.records[1], HeaderContentType))
+	var result publisherpb.Event
+	require.NoError(t, proto.Unmarshal(kafkaClient.records[1].Value, &result))
+	assert.Equal(t, uint64(2), result.EventId)
+}
@@ -0,0 +1,47 @@
+package sync
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"time"
+)
+
+// TickMarker is published after all events of a tick are published. Consumers know from it, that the data of a tick
+// is complete, also for ticks without events. Events that are sent to the dead letter topic are not part of count and
+// digest.
+type TickMarker struct {
+	Epoch           uint32    `json:"epoch"`
+	Tick            uint32    `json:"tick"`
+	EventCount      int       `json:"eventCount"`      // number of published events
+	EventDigest     string    `json:"eventDigest"`     // hex encoded sha256 over the little endian published event ids in publishing order
+	DeadLetterCount int       `json:"deadLetterCount"` // number of dead letters of the tick
+	PublishTime     time.Time `json:"publishTime"`
+}
+
+func createTickMarker(epoch, tick uint32, publishedEventIds []uint64, deadLetterCount int, publishTime time.Time) TickMarker {
+	hash := sha256.New()
+	for _, eventId := range publishedEventIds {
+		hash.Write(binary.LittleEndian.AppendUint64(nil, eventId))
+	}
+	return TickMarker{
+		Epoch:           epoch,
+		Tick:            tick,
+		EventCount:      len(publishedEventIds),
+		EventDigest:     hex.EncodeToString(hash.Sum(nil)),
+		DeadLetterCount: deadLetterCount,
+		PublishTime:     publishTime.UTC(),
+	}
+}
+
+func createTickMarkerRecord(topic string, marker TickMarker) (*kgo.Record, error) {
+	payload, err := json.Marshal(marker)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal tick marker")
+	}
+	key := binary.LittleEndian.AppendUint32(nil, marker.Tick)
+	return &kgo.Record{Topic: topic, Key: key, Value: payload}, nil
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"testing"
+	"time"
+)
+
+func TestTickMarker_createTickMarker(t *testing.T) {
+	publishTime := time.Date(2025, 3, 20, 17, 21, 11, 0, time.UTC)
+	marker := createTickMarker(123, 12345, []uint64{1, 2}, 1, publishTime)
+
+	expected := TickMarker{
+		Epoch:           123,
+		Tick:            12345,
+		EventCount:      2,
+		EventDigest:     "0c730b69905c5ef7a4ca5269f72365400bde2dd2c04eaf9bbb3d1c4a265a0131",
+		DeadLetterCount: 1,
+		PublishTime:     publishTime,
+	}
+	assert.Equal(t, expected, marker)
+
+	record, err := createTickMarkerRecord("marker-topic", marker)
+	require.NoError(t, err)
+	assert.Equal(t, "marker-topic", record.Topic)
+	assert.Equal(t, []byte{0x39, 0x30, 0, 0}, record.Key)
+	assert.JSONEq(t, `{"epoch":123,"tick":12345,"eventCount":2,"eventDigest":"0c730b69905c5ef7a4ca5269f72365400bde2dd2c04eaf9bbb3d1c4a265a0131","deadLetterCount":1,"publishTime":"2025-03-20T17:21:11Z"}`, string(record.Value))
+}
+
+func TestEventProducer_ProcessTickEvents_GivenTickMarkers_ThenPublishMarkerLast(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	pub := NewEventProducer(kafkaClient, WithTickMarkers("marker-topic"))
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, kafkaClient.records, 3)
+	assert.Equal(t, "marker-topic", kafkaClient.records[2].Topic)
+
+	var marker TickMarker
+	require.NoError(t, json.Unmarshal(kafkaClient.records[2].Value, &marker))
+	assert.Equal(t, uint32(123), marker.Epoch)
+	assert.Equal(t, uint32(12345), marker.Tick)
+	assert.Equal(t, 2, marker.EventCount)
+}
+
+func TestEventProducer_ProcessTickEvents_GivenDeadLetter_ThenMarkerWithoutDeadLetterEvent(t *testing.T) {
+	kafkaClient := &RejectingKafkaClient{
+		rejectedEventIds: map[string]bool{eventIdKey(100, 2): true},
+		rejectErr:        kerr.InvalidRecord,
+	}
+	pub := NewEventProducer(kafkaClient,
+		WithKeyStrategy(EventIdKeyStrategy{}),
+		WithDeadLetterQueue(NewDeadLetterQueue(testDeadLetterTopic, metrics)),
+		WithTickMarkers("marker-topic"),
+	)
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, deadLetterTestTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, kafkaClient.records, 5)
+	assert.Equal(t, "marker-topic", kafkaClient.records[4].Topic)
+
+	var marker TickMarker
+	require.NoError(t, json.Unmarshal(kafkaClient.records[4].Value, &marker))
+	assert.Equal(t, 2, marker.EventCount)
+	assert.Equal(t, 1, marker.DeadLetterCount)
+	assert.Equal(t, createTickMarker(123, 12345, []uint64{1, 3}, 1, time.Now()).EventDigest, marker.EventDigest)
+}
+
+func TestEventProducer_ProcessTickEvents_GivenEmptyTick_ThenPublishMarker(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	pub := NewEventProducer(kafkaClient, WithTickMarkers("marker-topic"))
+
+	count, err := pub.ProcessTickEvents(context.Background(), 123, &eventspb.TickEvents{Tick: 12345})
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	require.Len(t, kafkaClient.records, 1)
+
+	var marker TickMarker
+	require.NoError(t, json.Unmarshal(kafkaClient.records[0].Value, &marker))
+	assert.Equal(t, uint32(12345), marker.Tick)
+	assert.Equal(t, 0, marker.EventCount)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", marker.EventDigest)
+}
+
+func TestTransactionalEventProducer_ProcessTickEvents_GivenTickMarkers_ThenMarkerInTransaction(t *testing.T) {
+	kafkaClient := &FakeTransactionalKafkaClient{}
+	producer := NewTransactionalEventProducer(kafkaClient, NewEventProducer(kafkaClient, WithTickMarkers("marker-topic")))
+
+	_, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	require.NoError(t, err)
+	assert.Equal(t, 1, kafkaClient.committed)
+	assert.Len(t, kafkaClient.records, 3)
+}
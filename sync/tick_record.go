@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// DefaultTickRecordMaxBytes keeps tick records below the default kafka message size limit of 1 MB.
+const DefaultTickRecordMaxBytes = 900_000
+
+// TickRecord contains the events of one tick grouped by transaction. Huge ticks are split into multiple parts. The
+// events of one transaction can span consecutive parts.
+type TickRecord struct {
+	Epoch        uint32            `json:"epoch"`
+	Tick         uint32            `json:"tick"`
+	Part         int               `json:"part"`  // starts with 1
+	Parts        int               `json:"parts"` // number of records of the tick
+	EventCount   int               `json:"eventCount"`
+	Transactions []TickTransaction `json:"transactions"`
+}
+
+type TickTransaction struct {
+	TransactionHash string   `json:"transactionHash"`
+	Events          []*Event `json:"events"`
+}
+
+// tickEvent is an event collected for a tick record.
+type tickEvent struct {
+	event          *Event
+	digestMismatch bool
+}
+
+// TickAggregation additionally publishes the events of a tick as one record per tick to the tick topic. The events
+// are still published per event to their routed topics.
+type TickAggregation struct {
+	topic      string
+	eventTypes map[uint32]bool // nil for all event types
+	maxBytes   int
+}
+
+// NewTickAggregation creates the aggregation for the given tick topic and event types. If no event types are given, all
+// events are aggregated. Returns nil, if there is no tick topic.
+func NewTickAggregation(topic string, eventTypes []uint32, maxBytes int) (*TickAggregation, error) {
+	if topic == "" {
+		return nil, nil
+	}
+	if maxBytes <= 0 {
+		return nil, errors.Errorf("invalid tick record max bytes [%d]", maxBytes)
+	}
+	ta := &TickAggregation{
+		topic:    topic,
+		maxBytes: maxBytes,
+	}
+	if len(eventTypes) > 0 {
+		ta.eventTypes = map[uint32]bool{}
+		for _, eventType := range eventTypes {
+			ta.eventTypes[eventType] = true
+		}
+	}
+	return ta, nil
+}
+
+// aggregates returns true, if events of the type are published to the tick topic.
+func (ta *TickAggregation) aggregates(eventType uint32) bool {
+	return ta != nil && (ta.eventTypes == nil || ta.eventTypes[eventType])
+}
+
+// createTickRecords creates the tick records from the events (in publishing order). A new part is started, if the
+// record would exceed the maximum size. Single events that exceed the maximum size get a part of their own.
+func (ta *TickAggregation) createTickRecords(epoch, tick uint32, events []tickEvent) ([]TickRecord, error) {
+	const recordOverhead = 256     // record fields
+	const transactionOverhead = 64 // transaction hash, json overhead
+
+	var records []TickRecord
+	var current TickRecord
+	size := recordOverhead
+	for _, e := range events {
+		data, err := json.Marshal(e.event)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshalling event [%d]", e.event.EventId)
+		}
+		transactionSize := transactionOverhead + len(e.event.TransactionHash)
+		eventSize := len(data) + 1
+		if !current.endsWithTransaction(e.event.TransactionHash) {
+			eventSize += transactionSize
+		}
+		if current.EventCount > 0 && size+eventSize > ta.maxBytes {
+			records = append(records, current)
+			current = TickRecord{}
+			size = recordOverhead
+			eventSize = len(data) + 1 + transactionSize
+		}
+		if !current.endsWithTransaction(e.event.TransactionHash) {
+			current.Transactions = append(current.Transactions, TickTransaction{TransactionHash: e.event.TransactionHash})
+		}
+		transaction := &current.Transactions[len(current.Transactions)-1]
+		transaction.Events = append(transaction.Events, e.event)
+		current.EventCount++
+		size += eventSize
+	}
+	if current.EventCount > 0 {
+		records = append(records, current)
+	}
+
+	for i := range records {
+		records[i].Epoch = epoch
+		records[i].Tick = tick
+		records[i].Part = i + 1
+		records[i].Parts = len(records)
+	}
+	return records, nil
+}
+
+// createTickRecords creates the kafka records for the tick topic. The records are keyed by tick, so that all parts are
+// sent to the same partition.
+func (ep *EventProducer) createTickRecords(epoch, tick uint32, events []tickEvent) ([]*kgo.Record, error) {
+	tickRecords, err := ep.ticks.createTickRecords(epoch, tick, events)
+	if err != nil {
+		return nil, err
+	}
+	mismatches := map[*Event]bool{}
+	for _, e := range events {
+		if e.digestMismatch {
+			mismatches[e.event] = true
+		}
+	}
+
+	records := make([]*kgo.Record, 0, len(tickRecords))
+	for _, tickRecord := range tickRecords {
+		value, err := json.Marshal(tickRecord)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal tick record")
+		}
+		record := &kgo.Record{
+			Topic:   ep.ticks.topic,
+			Key:     binary.LittleEndian.AppendUint32(nil, tick),
+			Value:   value,
+			Headers: ep.headers.createAggregated(&Event{Epoch: epoch, Tick: tick}, jsonContentType, SchemaVersion1),
+		}
+		if containsMismatch(tickRecord, mismatches) {
+			record.Headers = append(record.Headers, digestMismatchHeader)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (tr *TickRecord) endsWithTransaction(transactionHash string) bool {
+	return len(tr.Transactions) > 0 && tr.Transactions[len(tr.Transactions)-1].TransactionHash == transactionHash
+}
+
+func containsMismatch(tickRecord TickRecord, mismatches map[*Event]bool) bool {
+	for _, transaction := range tickRecord.Transactions {
+		for _, event := range transaction.Events {
+			if mismatches[event] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewTickAggregation(t *testing.T) {
+	ticks, err := NewTickAggregation("", nil, DefaultTickRecordMaxBytes)
+	assert.NoError(t, err)
+	assert.Nil(t, ticks)
+	assert.False(t, ticks.aggregates(0))
+
+	ticks, err = NewTickAggregation("topic", nil, DefaultTickRecordMaxBytes)
+	require.NoError(t, err)
+	assert.True(t, ticks.aggregates(0))
+	assert.True(t, ticks.aggregates(255))
+
+	ticks, err = NewTickAggregation("topic", []uint32{0, 8}, DefaultTickRecordMaxBytes)
+	require.NoError(t, err)
+	assert.True(t, ticks.aggregates(0))
+	assert.True(t, ticks.aggregates(8))
+	assert.False(t, ticks.aggregates(1))
+
+	_, err = NewTickAggregation("topic", nil, 0)
+	assert.Error(t, err)
+}
+
+// tickRecordTestEvents returns three events of two transactions.
+func tickRecordTestEvents() []tickEvent {
+	var events []tickEvent
+	for i, transactionHash := range []string{"tx-1", "tx-1", "tx-2"} {
+		event := testTransferEvent()
+		event.EventId = uint64(i + 1)
+		event.TransactionHash = transactionHash
+		events = append(events, tickEvent{event: event})
+	}
+	return events
+}
+
+func TestTickAggregation_createTickRecords_ThenGroupByTransaction(t *testing.T) {
+	ticks, err := NewTickAggregation("topic", nil, DefaultTickRecordMaxBytes)
+	require.NoError(t, err)
+
+	events := tickRecordTestEvents()
+	records, err := ticks.createTickRecords(153, 21679416, events)
+	require.NoError(t, err)
+	assert.Equal(t, []TickRecord{{
+		Epoch:      153,
+		Tick:       21679416,
+		Part:       1,
+		Parts:      1,
+		EventCount: 3,
+		Transactions: []TickTransaction{
+			{TransactionHash: "tx-1", Events: []*Event{events[0].event, events[1].event}},
+			{TransactionHash: "tx-2", Events: []*Event{events[2].event}},
+		},
+	}}, records)
+}
+
+func TestTickAggregation_createTickRecords_GivenHugeTick_ThenSplit(t *testing.T) {
+	ticks, err := NewTickAggregation("topic", nil, 1000)
+	require.NoError(t, err)
+
+	events := tickRecordTestEvents()
+	records, err := ticks.createTickRecords(153, 21679416, events)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, 1, records[0].Part)
+	assert.Equal(t, 2, records[0].Parts)
+	assert.Equal(t, 2, records[0].EventCount)
+	assert.Equal(t, []TickTransaction{{TransactionHash: "tx-1", Events: []*Event{events[0].event, events[1].event}}}, records[0].Transactions)
+
+	assert.Equal(t, 2, records[1].Part)
+	assert.Equal(t, 2, records[1].Parts)
+	assert.Equal(t, 1, records[1].EventCount)
+	assert.Equal(t, []TickTransaction{{TransactionHash: "tx-2", Events: []*Event{events[2].event}}}, records[1].Transactions)
+
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(data), 1000)
+	}
+}
+
+func TestTickAggregation_createTickRecords_GivenEventLargerThanMaxBytes_ThenOneEventPerPart(t *testing.T) {
+	ticks, err := NewTickAggregation("topic", nil, 1)
+	require.NoError(t, err)
+
+	records, err := ticks.createTickRecords(153, 21679416, tickRecordTestEvents())
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "tx-1", records[1].Transactions[0].TransactionHash) // transaction spans parts
+	assert.Equal(t, 3, records[2].Part)
+}
+
+func TestEventProducer_ProcessTickEvents_GivenTickTopic_ThenPublishEventAndTickStreams(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	ticks, err := NewTickAggregation("tick-topic", []uint32{0}, DefaultTickRecordMaxBytes)
+	require.NoError(t, err)
+	headers, err := NewRecordHeaders(AllHeaders, "v1.2.3", "localhost:8003")
+	require.NoError(t, err)
+	pub := NewEventProducer(kafkaClient,
+		WithTopicRouter(NewTopicRouter("event-topic", map[uint32]string{1: "asset-topic"})),
+		WithTickAggregation(ticks),
+		WithRecordHeaders(headers),
+	)
+
+	tickEvents := &eventspb.TickEvents{
+		Tick: 12345,
+		TxEvents: []*eventspb.TransactionEvents{
+			{TxId: "tx-id-1", Events: []*eventspb.Event{
+				{Header: &eventspb.Event_Header{Epoch: 123, EventId: 1}},
+				{Header: &eventspb.Event_Header{Epoch: 123, EventId: 2}, EventType: 1},
+			}},
+			{TxId: "tx-id-2", Events: []*eventspb.Event{
+				{Header: &eventspb.Event_Header{Epoch: 123, EventId: 3}},
+			}},
+		},
+	}
+	count, err := pub.ProcessTickEvents(context.Background(), 123, tickEvents)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.Len(t, kafkaClient.records, 4)
+
+	// every event is published to its routed topic
+	assert.Equal(t, "event-topic", kafkaClient.records[0].Topic)
+	assert.Equal(t, "1", headerValue(kafkaClient.records[0], HeaderEventId))
+	assert.Equal(t, "asset-topic", kafkaClient.records[1].Topic)
+	assert.Equal(t, "2", headerValue(kafkaClient.records[1], HeaderEventId))
+	assert.Equal(t, "event-topic", kafkaClient.records[2].Topic)
+	assert.Equal(t, "3", headerValue(kafkaClient.records[2], HeaderEventId))
+
+	// events of the selected types are also part of the tick record
+	tickRecord := kafkaClient.records[3]
+	assert.Equal(t, "tick-topic", tickRecord.Topic)
+	assert.Equal(t, []byte{0x39, 0x30, 0, 0}, tickRecord.Key)
+	assert.Equal(t, "12345", headerValue(tickRecord, HeaderTick))
+	assert.Empty(t, headerValue(tickRecord, HeaderEventId))
+	var value TickRecord
+	require.NoError(t, json.Unmarshal(tickRecord.Value, &value))
+	assert.Equal(t, 2, value.EventCount)
+	require.Len(t, value.Transactions, 2)
+	assert.Equal(t, "tx-id-1", value.Transactions[0].TransactionHash)
+	assert.Equal(t, uint64(1), value.Transactions[0].Events[0].EventId)
+	assert.Equal(t, "tx-id-2", value.Transactions[1].TransactionHash)
+	assert.Equal(t, uint64(3), value.Transactions[1].Events[0].EventId)
+}
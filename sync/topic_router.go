@@ -0,0 +1,25 @@
+package sync
+
+// TopicRouter maps event types to target topics. Events without explicit route are sent to the default topic.
+type TopicRouter struct {
+	defaultTopic string
+	routes       map[uint32]string
+}
+
+func NewTopicRouter(defaultTopic string, routes map[uint32]string) *TopicRouter {
+	return &TopicRouter{
+		defaultTopic: defaultTopic,
+		routes:       routes,
+	}
+}
+
+func (tr *TopicRouter) Topic(eventType uint32) string {
+	if tr == nil {
+		return "" // client default produce topic
+	}
+	topic, ok := tr.routes[eventType]
+	if !ok || topic == "" {
+		return tr.defaultTopic
+	}
+	return topic
+}
@@ -0,0 +1,26 @@
+package sync
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTopicRouter_Topic(t *testing.T) {
+	router := NewTopicRouter("default-topic", map[uint32]string{
+		0: "transfer-topic",
+		1: "asset-topic",
+		2: "asset-topic",
+		3: "",
+	})
+
+	assert.Equal(t, "transfer-topic", router.Topic(0))
+	assert.Equal(t, "asset-topic", router.Topic(1))
+	assert.Equal(t, "asset-topic", router.Topic(2))
+	assert.Equal(t, "default-topic", router.Topic(3))
+	assert.Equal(t, "default-topic", router.Topic(255))
+}
+
+func TestTopicRouter_GivenNoRouter_ThenEmptyTopic(t *testing.T) {
+	var router *TopicRouter
+	assert.Equal(t, "", router.Topic(0))
+}
@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/qubic/go-events-publisher/payload"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	OutcomeSuccess = "success" // no contract error or warning messages
+	OutcomeWarning = "warning" // contract warning messages, but no error messages
+	OutcomeError   = "error"   // contract error messages
+)
+
+// TransactionMessage bundles all events of one transaction in publishing order with an outcome and summary fields.
+type TransactionMessage struct {
+	Epoch            uint32   `json:"epoch"`
+	Tick             uint32   `json:"tick"`
+	TransactionHash  string   `json:"transactionHash"`
+	Outcome          string   `json:"outcome"`
+	EventCount       int      `json:"eventCount"`
+	ContractErrors   int      `json:"contractErrors"`   // number of contract error messages
+	ContractWarnings int      `json:"contractWarnings"` // number of contract warning messages
+	QuTransferred    int64    `json:"quTransferred"`    // sum of the qu transfer amounts
+	QuBurned         int64    `json:"quBurned"`         // sum of the burned and dust burned amounts
+	Events           []*Event `json:"events"`
+}
+
+// createTransactionMessage classifies the outcome and computes the summary fields. Event data, that cannot be
+// decoded, is not part of the summary.
+func createTransactionMessage(epoch, tick uint32, transactionHash string, events []*Event) TransactionMessage {
+	message := TransactionMessage{
+		Epoch:           epoch,
+		Tick:            tick,
+		TransactionHash: transactionHash,
+		EventCount:      len(events),
+		Events:          events,
+	}
+	for _, event := range events {
+		switch event.EventType {
+		case payload.TypeContractErrorMessage:
+			message.ContractErrors++
+		case payload.TypeContractWarningMessage:
+			message.ContractWarnings++
+		}
+
+		decoded := event.Payload
+		if decoded == nil {
+			data, err := decodeEventData(event)
+			if err != nil {
+				continue
+			}
+			decoded, _ = payload.Decode(event.EventType, data)
+		}
+		switch p := decoded.(type) {
+		case payload.QuTransfer:
+			message.QuTransferred += p.Amount
+		case payload.Burning:
+			message.QuBurned += p.Amount
+		case payload.DustBurning:
+			for _, entity := range p.Entities {
+				message.QuBurned += int64(entity.Amount)
+			}
+		}
+	}
+
+	switch {
+	case message.ContractErrors > 0:
+		message.Outcome = OutcomeError
+	case message.ContractWarnings > 0:
+		message.Outcome = OutcomeWarning
+	default:
+		message.Outcome = OutcomeSuccess
+	}
+	return message
+}
+
+// createTransactionRecord creates the record for the transaction topic. The record is keyed by transaction hash.
+func (ep *EventProducer) createTransactionRecord(epoch, tick uint32, transactionHash string, events []*Event, digestMismatch bool) (*kgo.Record, error) {
+	message := createTransactionMessage(epoch, tick, transactionHash, events)
+	value, err := json.Marshal(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal transaction message")
+	}
+	record := &kgo.Record{
+		Topic:   ep.transactionTopic,
+		Key:     []byte(transactionHash),
+		Value:   value,
+		Headers: ep.headers.createAggregated(&Event{Epoch: epoch, Tick: tick, TransactionHash: transactionHash}, jsonContentType, SchemaVersion1),
+	}
+	if digestMismatch {
+		record.Headers = append(record.Headers, digestMismatchHeader)
+	}
+	return record, nil
+}
@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// contractMessageData returns the base64 encoded data of a contract message without body.
+func contractMessageData(contractIndex uint32) string {
+	data := binary.LittleEndian.AppendUint32(nil, contractIndex)
+	data = binary.LittleEndian.AppendUint32(data, 1)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// burningData returns the base64 encoded data of a burning event of the zero public key.
+func burningData(amount uint64) string {
+	data := binary.LittleEndian.AppendUint64(make([]byte, 32), amount)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func TestCreateTransactionMessage(t *testing.T) {
+	transfer := testTransferEvent()
+	burning := &Event{EventType: 8, EventData: burningData(1000)}
+	warning := &Event{EventType: 5, EventData: contractMessageData(1)}
+	contractError := &Event{EventType: 4, EventData: contractMessageData(1)}
+	undecodable := &Event{EventType: 0, EventData: "AAAA"}
+
+	tests := []struct {
+		name     string
+		events   []*Event
+		expected TransactionMessage
+	}{
+		{
+			name:     "success",
+			events:   []*Event{transfer, transfer, burning, undecodable},
+			expected: TransactionMessage{Outcome: OutcomeSuccess, EventCount: 4, QuTransferred: 2, QuBurned: 1000},
+		},
+		{
+			name:     "warning",
+			events:   []*Event{transfer, warning},
+			expected: TransactionMessage{Outcome: OutcomeWarning, EventCount: 2, ContractWarnings: 1, QuTransferred: 1},
+		},
+		{
+			name:     "error",
+			events:   []*Event{warning, contractError, contractError},
+			expected: TransactionMessage{Outcome: OutcomeError, EventCount: 3, ContractErrors: 2, ContractWarnings: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := createTransactionMessage(153, 21679416, "tx-hash", tt.events)
+			tt.expected.Epoch = 153
+			tt.expected.Tick = 21679416
+			tt.expected.TransactionHash = "tx-hash"
+			tt.expected.Events = tt.events
+			assert.Equal(t, tt.expected, message)
+		})
+	}
+}
+
+func TestEventProducer_ProcessTickEvents_GivenTransactionTopic_ThenPublishTransactionMessages(t *testing.T) {
+	kafkaClient := &FakeKafkaClient{}
+	headers, err := NewRecordHeaders(AllHeaders, "v1.2.3", "localhost:8003")
+	require.NoError(t, err)
+	pub := NewEventProducer(kafkaClient,
+		WithTopicRouter(NewTopicRouter("topic", nil)),
+		WithRecordHeaders(headers),
+		WithTransactionTopic("transaction-topic"),
+	)
+
+	event := testTransferEvent()
+	tickEvents := &eventspb.TickEvents{
+		Tick: event.Tick,
+		TxEvents: []*eventspb.TransactionEvents{
+			{TxId: event.TransactionHash, Events: []*eventspb.Event{
+				{Header: &eventspb.Event_Header{Epoch: 153, EventId: 1}, EventData: event.EventData},
+				{Header: &eventspb.Event_Header{Epoch: 153, EventId: 2}, EventType: 4, EventData: contractMessageData(1)},
+			}},
+			{TxId: "", Events: []*eventspb.Event{
+				{Header: &eventspb.Event_Header{Epoch: 153, EventId: 3}, EventType: 10},
+			}},
+		},
+	}
+	count, err := pub.ProcessTickEvents(context.Background(), 153, tickEvents)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count) // transaction messages are not counted
+	require.Len(t, kafkaClient.records, 4)
+
+	record := kafkaClient.records[2]
+	assert.Equal(t, "transaction-topic", record.Topic)
+	assert.Equal(t, []byte(event.TransactionHash), record.Key)
+	assert.Equal(t, event.TransactionHash, headerValue(record, HeaderTransactionHash))
+	assert.Empty(t, headerValue(record, HeaderEventId))
+
+	var message TransactionMessage
+	require.NoError(t, json.Unmarshal(record.Value, &message))
+	assert.Equal(t, OutcomeError, message.Outcome)
+	assert.Equal(t, 2, message.EventCount)
+	assert.Equal(t, int64(1), message.QuTransferred)
+	require.Len(t, message.Events, 2)
+	assert.Equal(t, uint64(1), message.Events[0].EventId)
+	assert.Equal(t, uint64(2), message.Events[1].EventId)
+
+	assert.Equal(t, "topic", kafkaClient.records[3].Topic) // no transaction message for events without transaction
+}
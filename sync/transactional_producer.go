@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"log"
+)
+
+type TransactionalKafkaClient interface {
+	KafkaClient
+	BeginTransaction() error
+	AbortBufferedRecords(ctx context.Context) error
+	EndTransaction(ctx context.Context, commit kgo.TransactionEndTry) error
+}
+
+// TransactionalEventProducer wraps a producer and publishes all records of one tick (including dead letters and tick
+// marker) within one kafka transaction. Either all records of the tick are committed or none. Consumers need to use
+// read_committed isolation.
+type TransactionalEventProducer struct {
+	kcl      TransactionalKafkaClient
+	producer Producer
+}
+
+func NewTransactionalEventProducer(client TransactionalKafkaClient, producer Producer) *TransactionalEventProducer {
+	return &TransactionalEventProducer{
+		kcl:      client,
+		producer: producer,
+	}
+}
+
+func (tp *TransactionalEventProducer) ProcessTickEvents(ctx context.Context, epoch uint32, tickEvents *eventspb.TickEvents) (int, error) {
+	tick := tickEvents.GetTick()
+
+	err := tp.kcl.BeginTransaction()
+	if err != nil {
+		return 0, errors.Wrapf(err, "beginning transaction for tick [%d]", tick)
+	}
+
+	count, err := tp.producer.ProcessTickEvents(ctx, epoch, tickEvents)
+	if err != nil {
+		abortErr := tp.abort(ctx)
+		if abortErr != nil {
+			log.Printf("Error aborting transaction for tick [%d]: %v", tick, abortErr)
+		}
+		return 0, errors.Wrapf(err, "producing transactional records for tick [%d]", tick)
+	}
+
+	// the producer waited for all records to be acknowledged. Nothing is buffered anymore.
+	err = tp.kcl.EndTransaction(ctx, kgo.TryCommit)
+	if err != nil {
+		return 0, errors.Wrapf(err, "committing transaction for tick [%d]", tick)
+	}
+
+	return count, nil
+}
+
+func (tp *TransactionalEventProducer) abort(ctx context.Context) error {
+	err := tp.kcl.AbortBufferedRecords(ctx)
+	if err != nil {
+		return errors.Wrap(err, "aborting buffered records")
+	}
+	err = tp.kcl.EndTransaction(ctx, kgo.TryAbort)
+	if err != nil {
+		return errors.Wrap(err, "aborting transaction")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	eventspb "github.com/qubic/go-events/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"testing"
+)
+
+type FakeTransactionalKafkaClient struct {
+	FakeKafkaClient
+	begun            int
+	committed        int
+	aborted          int
+	commitErr        error
+	transactionStart int
+	committedRecords []*kgo.Record
+}
+
+func (fkc *FakeTransactionalKafkaClient) BeginTransaction() error {
+	fkc.begun++
+	fkc.transactionStart = len(fkc.records)
+	return nil
+}
+
+func (fkc *FakeTransactionalKafkaClient) AbortBufferedRecords(_ context.Context) error {
+	return nil
+}
+
+func (fkc *FakeTransactionalKafkaClient) EndTransaction(_ context.Context, commit kgo.TransactionEndTry) error {
+	if commit == kgo.TryCommit {
+		if fkc.commitErr != nil {
+			return fkc.commitErr
+		}
+		fkc.committed++
+		fkc.committedRecords = append(fkc.committedRecords, fkc.records[fkc.transactionStart:]...)
+	} else {
+		fkc.aborted++
+	}
+	return nil
+}
+
+func testTickEvents() *eventspb.TickEvents {
+	return &eventspb.TickEvents{
+		Tick: 12345,
+		TxEvents: []*eventspb.TransactionEvents{
+			{
+				TxId: "tx-id-1",
+				Events: []*eventspb.Event{
+					{Header: &eventspb.Event_Header{EventId: 1}},
+					{Header: &eventspb.Event_Header{EventId: 2}},
+				},
+			},
+		},
+	}
+}
+
+func TestTransactionalEventProducer_ProcessTickEvents_ThenCommit(t *testing.T) {
+	kafkaClient := &FakeTransactionalKafkaClient{}
+	producer := NewTransactionalEventProducer(kafkaClient, &EventProducer{kcl: kafkaClient})
+
+	count, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1, kafkaClient.begun)
+	assert.Equal(t, 1, kafkaClient.committed)
+	assert.Equal(t, 0, kafkaClient.aborted)
+}
+
+func TestTransactionalEventProducer_ProcessTickEvents_GivenProduceError_ThenAbort(t *testing.T) {
+	kafkaClient := &FakeTransactionalKafkaClient{}
+	kafkaClient.produceErr = errors.New("test error")
+	producer := NewTransactionalEventProducer(kafkaClient, &EventProducer{kcl: kafkaClient})
+
+	count, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, kafkaClient.begun)
+	assert.Equal(t, 0, kafkaClient.committed)
+	assert.Equal(t, 1, kafkaClient.aborted)
+}
+
+func TestTransactionalEventProducer_ProcessTickEvents_GivenCommitError_ThenReturnError(t *testing.T) {
+	kafkaClient := &FakeTransactionalKafkaClient{commitErr: errors.New("test error")}
+	producer := NewTransactionalEventProducer(kafkaClient, &EventProducer{kcl: kafkaClient})
+
+	count, err := producer.ProcessTickEvents(context.Background(), 123, testTickEvents())
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0, kafkaClient.committed)
+}